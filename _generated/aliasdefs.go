@@ -0,0 +1,21 @@
+package _generated
+
+//go:generate csmsgp2go
+
+// Definitions used by the types in aliases.go.
+
+type Person struct {
+	Name string `msg:"0"`
+	Age  int32  `msg:"2"`
+}
+
+type People []Person
+
+type Counts map[string]int64
+
+type Places = []string
+
+// AltPerson is an alias declared in another file.
+type AltPerson = Person
+
+type TagAlias = string
@@ -0,0 +1,32 @@
+package _generated
+
+//go:generate csmsgp2go
+
+// Types defined over, or aliasing, the types in aliasdefs.go,
+// which is generated on its own.
+
+// Member is a struct defined over Person from aliasdefs.go.
+type Member Person
+
+// Roster is a slice defined over People from aliasdefs.go.
+type Roster People
+
+// Tally is a map defined over Counts from aliasdefs.go.
+type Tally Counts
+
+// Owner is an alias; it has the methods of Person.
+type Owner = Person
+
+// Level is an alias of a builtin type.
+type Level = int16
+
+type Team struct {
+	Lead    Owner      `msg:"0"`
+	Level   Level      `msg:"1"`
+	Members []Member   `msg:"2"`
+	Places  Places     `msg:"3"`
+	Scores  Tally      `msg:"4"`
+	Roster  Roster     `msg:"5"`
+	Alt     AltPerson  `msg:"6"`
+	Tags    []TagAlias `msg:"7"`
+}
@@ -0,0 +1,51 @@
+package _generated
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestDefinedOverOtherFile(t *testing.T) {
+	in := Team{
+		Lead:    Owner{Name: "ann", Age: 40},
+		Level:   3,
+		Members: []Member{{Name: "bob", Age: 20}},
+		Places:  Places{"here", "there"},
+		Scores:  Tally{"bob": 7},
+		Roster:  Roster{{Name: "cy", Age: 30}},
+		Alt:     AltPerson{Name: "dee"},
+		Tags:    []TagAlias{"x"},
+	}
+	bts, err := in.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out Team
+	if _, err = out.UnmarshalMsg(bts); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("got %+v; want %+v", out, in)
+	}
+
+	// Member has the wire form of Person
+	m := Member{Name: "bob", Age: 20}
+	mb, err := m.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := Person(m)
+	pb, err := p.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(mb) != string(pb) {
+		t.Errorf("Member is %x; Person is %x", mb, pb)
+	}
+
+	// an alias has the methods of its target
+	var _ msgp.Marshaler = &Owner{}
+	var _ msgp.Marshaler = &AltPerson{}
+}
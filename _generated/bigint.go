@@ -0,0 +1,13 @@
+package _generated
+
+import "math/big"
+
+//go:generate csmsgp2go -io -exactsize -diff
+
+type Balance struct {
+	Owner   string     `msg:"0"`
+	Amount  big.Int    `msg:"1"`
+	Limit   *big.Int   `msg:"2"`
+	History []big.Int  `msg:"3"`
+	Pending []*big.Int `msg:"4"`
+}
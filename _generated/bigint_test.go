@@ -0,0 +1,85 @@
+package _generated
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestBigIntFields(t *testing.T) {
+	huge, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+	in := Balance{
+		Owner:   "alice",
+		Limit:   big.NewInt(1000),
+		History: []big.Int{*big.NewInt(-129), *huge},
+		Pending: []*big.Int{big.NewInt(255), nil},
+	}
+	in.Amount.SetInt64(128)
+
+	bts, err := in.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := in.ExactMsgsize(); n != len(bts) {
+		t.Errorf("ExactMsgsize() = %d, want %d", n, len(bts))
+	}
+	if n := in.Msgsize(); n < len(bts) {
+		t.Errorf("Msgsize() = %d, less than %d", n, len(bts))
+	}
+	// 128 is the little-endian two's complement 80 00
+	amount := msgp.AppendBytes(nil, []byte{0x80, 0x00})
+	if !bytes.Contains(bts, amount) {
+		t.Errorf("MarshalMsg() = %x, missing Amount %x", bts, amount)
+	}
+
+	check := func(out *Balance) {
+		t.Helper()
+		if out.Amount.Cmp(&in.Amount) != 0 || out.Limit.Cmp(in.Limit) != 0 {
+			t.Errorf("Amount, Limit = %s, %s; want %s, %s", &out.Amount, out.Limit, &in.Amount, in.Limit)
+		}
+		if len(out.History) != 2 || out.History[0].Cmp(&in.History[0]) != 0 || out.History[1].Cmp(huge) != 0 {
+			t.Errorf("History = %v", out.History)
+		}
+		// nil is written as zero
+		if len(out.Pending) != 2 || out.Pending[0].Int64() != 255 || out.Pending[1] == nil || out.Pending[1].Sign() != 0 {
+			t.Errorf("Pending = %v", out.Pending)
+		}
+	}
+
+	var out Balance
+	if _, err = out.UnmarshalMsg(bts); err != nil {
+		t.Fatal(err)
+	}
+	check(&out)
+
+	var buf bytes.Buffer
+	if err = msgp.Encode(&buf, &in); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), bts) {
+		t.Errorf("EncodeMsg() = %x, want %x", buf.Bytes(), bts)
+	}
+	out = Balance{}
+	if err = msgp.Decode(&buf, &out); err != nil {
+		t.Fatal(err)
+	}
+	check(&out)
+}
+
+func TestBigIntDiff(t *testing.T) {
+	old := Balance{Limit: big.NewInt(1)}
+	cur := Balance{Limit: big.NewInt(1)}
+	cur.Amount.SetInt64(-5)
+	patch, err := cur.Diff(&old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = old.ApplyPatch(patch); err != nil {
+		t.Fatal(err)
+	}
+	if old.Amount.Int64() != -5 {
+		t.Errorf("Amount = %s, want -5", &old.Amount)
+	}
+}
@@ -0,0 +1,15 @@
+package _generated
+
+import (
+	"time"
+
+	"github.com/aggronmagi/csmsgp2go/csmsgp"
+)
+
+//go:generate csmsgp2go -io -exactsize
+
+type OffsetEvent struct {
+	At      csmsgp.DateTimeOffset   `msg:"0"`
+	History []csmsgp.DateTimeOffset `msg:"1"`
+	Plain   time.Time               `msg:"2"`
+}
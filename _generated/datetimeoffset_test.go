@@ -0,0 +1,37 @@
+package _generated
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aggronmagi/csmsgp2go/csmsgp"
+)
+
+func TestDateTimeOffsetField(t *testing.T) {
+	zone := time.FixedZone("", -5*60*60)
+	in := OffsetEvent{
+		At:      csmsgp.DateTimeOffset{Time: time.Date(2024, 1, 2, 3, 4, 5, 600, zone)},
+		History: []csmsgp.DateTimeOffset{{Time: time.Unix(100, 0).UTC()}},
+		Plain:   time.Unix(200, 0),
+	}
+	bts, err := in.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := in.ExactMsgsize(); n != len(bts) {
+		t.Errorf("ExactMsgsize() = %d, want %d", n, len(bts))
+	}
+	var out OffsetEvent
+	if _, err = out.UnmarshalMsg(bts); err != nil {
+		t.Fatal(err)
+	}
+	if !out.At.Equal(in.At.Time) {
+		t.Errorf("At = %v, want %v", out.At, in.At)
+	}
+	if _, off := out.At.Zone(); off != -5*60*60 {
+		t.Errorf("At offset = %d, want %d", off, -5*60*60)
+	}
+	if len(out.History) != 1 || !out.History[0].Equal(in.History[0].Time) {
+		t.Errorf("History = %v, want %v", out.History, in.History)
+	}
+}
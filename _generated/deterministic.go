@@ -0,0 +1,13 @@
+package _generated
+
+//go:generate csmsgp2go -io
+
+//msgp:deterministic
+
+type DetSnapshot struct {
+	Scores map[string]int32           `msg:"0"`
+	Nested map[int64]map[string]int32 `msg:"1"`
+	Named  DetIndex                   `msg:"2"`
+}
+
+type DetIndex map[uint16]string
@@ -0,0 +1,83 @@
+package _generated
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestDeterministicMaps(t *testing.T) {
+	in := DetSnapshot{
+		Scores: make(map[string]int32),
+		Nested: make(map[int64]map[string]int32),
+		Named:  make(DetIndex),
+	}
+	for i := 0; i < 40; i++ {
+		in.Scores[fmt.Sprint("player", i)] = int32(i)
+		in.Nested[int64(i)] = map[string]int32{"a": 1, "b": 2, "c": 3}
+		in.Named[uint16(i*7)] = fmt.Sprint(i)
+	}
+	first, err := in.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		again, err := in.MarshalMsg(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(again, first) {
+			t.Fatal("MarshalMsg output differs between runs")
+		}
+		var buf bytes.Buffer
+		if err = msgp.Encode(&buf, &in); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf.Bytes(), first) {
+			t.Fatal("EncodeMsg output differs from MarshalMsg")
+		}
+	}
+
+	// keys are written in ascending order
+	sz, o, err := msgp.ReadMapHeaderBytes(first[1:]) // skip the struct header
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := ""
+	for i := uint32(0); i < sz; i++ {
+		var k string
+		if k, o, err = msgp.ReadStringBytes(o); err != nil {
+			t.Fatal(err)
+		}
+		if k < prev {
+			t.Fatalf("key %q written after %q", k, prev)
+		}
+		prev = k
+		if o, err = msgp.Skip(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSortedFieldOption(t *testing.T) {
+	in := SortedField{Sorted: make(map[string]int32), Groups: []map[int16]string{{}}}
+	for i := 0; i < 40; i++ {
+		in.Sorted[fmt.Sprint(i)] = int32(i)
+		in.Groups[0][int16(-i)] = fmt.Sprint(i)
+	}
+	first, err := in.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		again, err := in.MarshalMsg(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(again, first) {
+			t.Fatal("MarshalMsg output differs between runs")
+		}
+	}
+}
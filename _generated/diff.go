@@ -0,0 +1,25 @@
+package _generated
+
+import "time"
+
+//go:generate csmsgp2go -diff
+
+type DiffPlayer struct {
+	ID    int64   `msg:"0"`
+	Name  string  `msg:"1"`
+	Pos   DiffVec `msg:"2"`
+	Stats struct {
+		HP    int32 `msg:"0"`
+		Level int16 `msg:"1"`
+	} `msg:"3"`
+	Tags    []string       `msg:"5"`
+	Data    []byte         `msg:"6"`
+	Seen    time.Time      `msg:"7"`
+	Counter map[string]int `msg:"8"`
+}
+
+type DiffVec struct {
+	X float32 `msg:"0"`
+	Y float32 `msg:"1"`
+	Z float32 `msg:"2"`
+}
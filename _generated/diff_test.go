@@ -0,0 +1,53 @@
+package _generated
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffApplyPatch(t *testing.T) {
+	var old DiffPlayer
+	old.ID = 1
+	old.Name = "a"
+	old.Pos = DiffVec{X: 1, Y: 2, Z: 3}
+	old.Stats.HP = 100
+	old.Tags = []string{"x"}
+	old.Seen = time.Unix(100, 0).UTC()
+	old.Counter = map[string]int{"k": 1}
+
+	// no change: an empty patch
+	cur := old
+	patch, err := cur.Diff(&old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patch) != 3 {
+		t.Errorf("unchanged object produced a %d byte patch: %x", len(patch), patch)
+	}
+
+	cur.Pos.Y = 5
+	cur.Stats.Level = 2
+	cur.Data = []byte("d")
+	cur.Counter = map[string]int{"k": 2}
+	patch, err = cur.Diff(&old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, err := cur.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patch) >= len(full) {
+		t.Errorf("patch is %d bytes, full object is %d bytes", len(patch), len(full))
+	}
+
+	got := old
+	got.Counter = map[string]int{"k": 1}
+	if err = got.ApplyPatch(patch); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, cur) {
+		t.Errorf("patched object is %+v, want %+v", got, cur)
+	}
+}
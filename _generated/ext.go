@@ -0,0 +1,24 @@
+package _generated
+
+//go:generate csmsgp2go -exactsize
+
+//msgp:ext ExtVec3 code:10
+//msgp:ext ExtTag code:11
+
+type ExtVec3 struct {
+	X float32 `msg:"0"`
+	Y float32 `msg:"1"`
+	Z float32 `msg:"2"`
+}
+
+type ExtTag struct {
+	Name string `msg:"0"`
+	Bits []byte `msg:"1"`
+}
+
+type ExtHolder struct {
+	Pos   ExtVec3           `msg:"0"`
+	Path  []ExtVec3         `msg:"1"`
+	Tags  map[string]ExtTag `msg:"2"`
+	Other interface{}       `msg:"3"`
+}
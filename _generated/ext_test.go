@@ -0,0 +1,68 @@
+package _generated
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestExtDirective(t *testing.T) {
+	v := ExtVec3{X: 1, Y: 2, Z: 3}
+	bts, err := msgp.AppendExtension(nil, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// fixext16, type 10, then the body as a plain array
+	body, _ := v.MarshalMsg(nil)
+	if want := append([]byte{0xd8, 10}, body...); !bytes.Equal(bts, want) {
+		t.Fatalf("AppendExtension() = %x, want %x", bts, want)
+	}
+
+	in := ExtHolder{
+		Pos:  v,
+		Path: []ExtVec3{{X: 4}, {Y: 5}},
+		Tags: map[string]ExtTag{"a": {Name: "alpha", Bits: []byte{1, 2}}},
+	}
+	bts, err = in.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := in.ExactMsgsize(); n != len(bts) {
+		t.Errorf("ExactMsgsize() = %d, want %d", n, len(bts))
+	}
+	var out ExtHolder
+	if _, err = out.UnmarshalMsg(bts); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("round trip = %+v, want %+v", out, in)
+	}
+}
+
+func TestExtRegistered(t *testing.T) {
+	bts, err := msgp.AppendExtension(nil, &ExtTag{Name: "registered"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	i, _, err := msgp.ReadIntfBytes(bts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag, ok := i.(*ExtTag); !ok || tag.Name != "registered" {
+		t.Errorf("ReadIntfBytes() = %#v, want *ExtTag", i)
+	}
+}
+
+func TestExtWrongType(t *testing.T) {
+	tag := ExtTag{Name: "x"}
+	bts, err := msgp.AppendExtension(nil, &tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v ExtVec3
+	if _, err = msgp.ReadExtensionBytes(bts, &v); err == nil {
+		t.Error("expected an extension type error")
+	}
+}
@@ -0,0 +1,15 @@
+package _generated
+
+import "github.com/tinylib/msgp/msgp"
+
+//go:generate csmsgp2go -io
+
+type LazyEnvelope struct {
+	Route uint32   `msg:"0"`
+	Body  msgp.Raw `msg:"1,lazy:LazyBody"`
+}
+
+type LazyBody struct {
+	Name   string  `msg:"0"`
+	Values []int32 `msg:"1"`
+}
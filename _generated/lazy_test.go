@@ -0,0 +1,59 @@
+package _generated
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLazyEnvelope(t *testing.T) {
+	var in LazyEnvelope
+	in.Route = 7
+	body := LazyBody{Name: "body", Values: []int32{1, 2, 3}}
+	if err := in.SetBody(&body); err != nil {
+		t.Fatal(err)
+	}
+	bts, err := in.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var proxy LazyEnvelope
+	if _, err = proxy.UnmarshalMsg(bts); err != nil {
+		t.Fatal(err)
+	}
+	// forwarding must not change a single byte
+	fwd, err := proxy.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(fwd, bts) {
+		t.Fatalf("forwarded bytes differ:\n%x\n%x", fwd, bts)
+	}
+
+	out, err := proxy.DecodeBody()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != body.Name || len(out.Values) != 3 || out.Values[2] != 3 {
+		t.Errorf("decoded %+v, want %+v", out, body)
+	}
+}
+
+func TestLazyEnvelopeEmpty(t *testing.T) {
+	var in LazyEnvelope
+	bts, err := in.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out LazyEnvelope
+	if _, err = out.UnmarshalMsg(bts); err != nil {
+		t.Fatal(err)
+	}
+	body, err := out.DecodeBody()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body.Name != "" || body.Values != nil {
+		t.Errorf("expected zero body, got %+v", body)
+	}
+}
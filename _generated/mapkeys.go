@@ -0,0 +1,23 @@
+package _generated
+
+//go:generate csmsgp2go -io -exactsize
+
+type UserID int64
+
+type ItemKind uint8
+
+const (
+	ItemWeapon ItemKind = iota + 1
+	ItemArmor
+)
+
+type ItemName string
+
+type MapKeys struct {
+	Players map[UserID]string   `msg:"0"`
+	Counts  map[ItemKind]int32  `msg:"1"`
+	Prices  map[ItemName]uint32 `msg:"2"`
+	Plain   map[int16]string    `msg:"3"`
+}
+
+type PlayerIndex map[UserID]ItemKind
@@ -0,0 +1,49 @@
+package _generated
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestNamedMapKeys(t *testing.T) {
+	in := MapKeys{
+		Players: map[UserID]string{1: "a", 1 << 40: "b"},
+		Counts:  map[ItemKind]int32{ItemWeapon: 3, ItemArmor: 4},
+		Prices:  map[ItemName]uint32{"sword": 100},
+		Plain:   map[int16]string{-1: "neg"},
+	}
+	bts, err := in.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out MapKeys
+	if _, err = out.UnmarshalMsg(bts); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("got %+v, want %+v", out, in)
+	}
+
+	// the wire form of a named key is its underlying primitive
+	sz, o, err := msgp.ReadMapHeaderBytes(bts[1:]) // skip the array header
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := make(map[int64]string, sz)
+	for i := uint32(0); i < sz; i++ {
+		var k int64
+		var v string
+		if k, o, err = msgp.ReadInt64Bytes(o); err != nil {
+			t.Fatal(err)
+		}
+		if v, o, err = msgp.ReadStringBytes(o); err != nil {
+			t.Fatal(err)
+		}
+		plain[k] = v
+	}
+	if plain[1<<40] != "b" {
+		t.Errorf("unexpected wire keys: %v", plain)
+	}
+}
@@ -0,0 +1,47 @@
+package _generated
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+//go:generate csmsgp2go -io -exactsize
+
+//msgp:ignore TextID BinID
+
+// TextID has text methods and no msgp methods.
+type TextID struct{ hi, lo string }
+
+func (t TextID) MarshalText() ([]byte, error) { return []byte(t.hi + ":" + t.lo), nil }
+
+func (t *TextID) UnmarshalText(b []byte) error {
+	hi, lo, ok := strings.Cut(string(b), ":")
+	if !ok {
+		return errors.New("bad TextID")
+	}
+	t.hi, t.lo = hi, lo
+	return nil
+}
+
+// BinID only has binary methods.
+type BinID struct{ v [2]byte }
+
+func (b BinID) MarshalBinary() ([]byte, error) { return b.v[:], nil }
+
+func (b *BinID) UnmarshalBinary(p []byte) error {
+	if len(p) != 2 {
+		return errors.New("bad BinID")
+	}
+	copy(b.v[:], p)
+	return nil
+}
+
+type Endpoint struct {
+	IP    net.IP       `msg:"0"`
+	Addr  netip.Addr   `msg:"1"`
+	Peers []netip.Addr `msg:"2"`
+	ID    TextID       `msg:"3"`
+	Bin   BinID        `msg:"4"`
+}
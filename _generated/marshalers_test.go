@@ -0,0 +1,69 @@
+package _generated
+
+import (
+	"bytes"
+	"net"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestMarshalerShims(t *testing.T) {
+	in := Endpoint{
+		IP:    net.ParseIP("192.168.1.2"),
+		Addr:  netip.MustParseAddr("fe80::1"),
+		Peers: []netip.Addr{netip.MustParseAddr("10.0.0.1")},
+		ID:    TextID{hi: "a", lo: "b"},
+		Bin:   BinID{v: [2]byte{7, 8}},
+	}
+	bts, err := in.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := in.ExactMsgsize(); n != len(bts) {
+		t.Errorf("ExactMsgsize() = %d, want %d", n, len(bts))
+	}
+
+	// text types are strings, binary types bin
+	want := msgp.AppendArrayHeader(nil, 5)
+	want = msgp.AppendString(want, "192.168.1.2")
+	want = msgp.AppendString(want, "fe80::1")
+	want = msgp.AppendArrayHeader(want, 1)
+	want = msgp.AppendString(want, "10.0.0.1")
+	want = msgp.AppendString(want, "a:b")
+	want = msgp.AppendBytes(want, []byte{7, 8})
+	if !bytes.Equal(bts, want) {
+		t.Fatalf("MarshalMsg() = %x, want %x", bts, want)
+	}
+
+	var out Endpoint
+	if _, err = out.UnmarshalMsg(bts); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("UnmarshalMsg() = %+v, want %+v", out, in)
+	}
+
+	var buf bytes.Buffer
+	if err = msgp.Encode(&buf, &in); err != nil {
+		t.Fatal(err)
+	}
+	out = Endpoint{}
+	if err = msgp.Decode(&buf, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("DecodeMsg() = %+v, want %+v", out, in)
+	}
+}
+
+func TestMarshalerShimError(t *testing.T) {
+	bts := msgp.AppendArrayHeader(nil, 5)
+	bts = msgp.AppendString(bts, "not an ip")
+	var out Endpoint
+	if _, err := out.UnmarshalMsg(bts); err == nil {
+		t.Error("expected an error for an invalid address")
+	}
+}
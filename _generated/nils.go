@@ -0,0 +1,19 @@
+package _generated
+
+//go:generate csmsgp2go -io
+
+type NilOuter struct {
+	Name   string            `msg:"0"`
+	List   []int32           `msg:"1"`
+	Dict   map[string]string `msg:"2"`
+	Fixed  [2]int16          `msg:"3"`
+	Inner  NilInner          `msg:"4"`
+	Blob   []byte            `msg:"5"`
+	Nested []NilInner        `msg:"6"`
+	After  int32             `msg:"7"`
+}
+
+type NilInner struct {
+	A int32  `msg:"0"`
+	B string `msg:"1"`
+}
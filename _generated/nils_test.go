@@ -0,0 +1,68 @@
+package _generated
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// nilPayload is what MessagePack-CSharp writes for a
+// NilOuter whose reference-typed members are all null.
+func nilPayload() []byte {
+	o := msgp.AppendArrayHeader(nil, 8)
+	for i := 0; i < 7; i++ {
+		o = msgp.AppendNil(o)
+	}
+	return msgp.AppendInt32(o, 42)
+}
+
+func TestUnmarshalNilMembers(t *testing.T) {
+	in := NilOuter{
+		Name:  "stale",
+		List:  []int32{1},
+		Dict:  map[string]string{"k": "v"},
+		Fixed: [2]int16{1, 2},
+		Inner: NilInner{A: 1, B: "b"},
+		Blob:  []byte("x"),
+	}
+	left, err := in.UnmarshalMsg(nilPayload())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 0 {
+		t.Errorf("%d bytes left over", len(left))
+	}
+	checkNilOuter(t, &in)
+}
+
+func TestDecodeNilMembers(t *testing.T) {
+	in := NilOuter{Name: "stale", List: []int32{1}, Inner: NilInner{A: 1}}
+	if err := msgp.Decode(bytes.NewReader(nilPayload()), &in); err != nil {
+		t.Fatal(err)
+	}
+	checkNilOuter(t, &in)
+}
+
+func TestUnmarshalNilRoot(t *testing.T) {
+	in := NilInner{A: 1, B: "b"}
+	if _, err := in.UnmarshalMsg(msgp.AppendNil(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if in != (NilInner{}) {
+		t.Errorf("nil root decoded as %+v", in)
+	}
+}
+
+func checkNilOuter(t *testing.T, v *NilOuter) {
+	t.Helper()
+	if v.Name != "" || len(v.List) != 0 || len(v.Dict) != 0 || len(v.Blob) != 0 || len(v.Nested) != 0 {
+		t.Errorf("collections not emptied: %+v", v)
+	}
+	if v.Fixed != [2]int16{} || v.Inner != (NilInner{}) {
+		t.Errorf("values not zeroed: %+v", v)
+	}
+	if v.After != 42 {
+		t.Errorf("After = %d, want 42", v.After)
+	}
+}
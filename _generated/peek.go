@@ -0,0 +1,13 @@
+package _generated
+
+//go:generate csmsgp2go
+
+type PeekEnvelope struct {
+	Route   uint32        `msg:"0,peek"`
+	Payload []PeekPayload `msg:"1"`
+	Trace   []byte        `msg:"4,peek"`
+}
+
+type PeekPayload struct {
+	Data []int64 `msg:"0"`
+}
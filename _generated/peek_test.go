@@ -0,0 +1,39 @@
+package _generated
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPeekEnvelope(t *testing.T) {
+	in := PeekEnvelope{
+		Route:   42,
+		Payload: []PeekPayload{{Data: []int64{1, 2, 3}}},
+		Trace:   []byte("trace"),
+	}
+	bts, err := in.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	route, err := PeekPeekEnvelopeRoute(bts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if route != in.Route {
+		t.Errorf("route = %d, want %d", route, in.Route)
+	}
+
+	// index 4 sits behind the payload and two nil placeholders
+	trace, err := PeekPeekEnvelopeTrace(bts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(trace, in.Trace) {
+		t.Errorf("trace = %q, want %q", trace, in.Trace)
+	}
+
+	if _, err = PeekPeekEnvelopeRoute(bts[:1]); err == nil {
+		t.Error("expected error for truncated input")
+	}
+}
@@ -0,0 +1,16 @@
+package _generated
+
+//go:generate csmsgp2go -io -exactsize
+
+type SetHolder struct {
+	Tags    map[string]struct{} `msg:"0"`
+	IDs     map[SetID]struct{}  `msg:"1"`
+	Flags   map[int32]bool      `msg:"2,set"`
+	Friends FriendSet           `msg:"3"`
+}
+
+type FriendSet map[SetName]struct{}
+
+type SetID int64
+
+type SetName string
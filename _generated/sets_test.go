@@ -0,0 +1,87 @@
+package _generated
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestSetRoundTrip(t *testing.T) {
+	in := SetHolder{
+		Tags:    map[string]struct{}{"b": {}, "a": {}, "c": {}},
+		IDs:     map[SetID]struct{}{3: {}, 1: {}, 2: {}},
+		Flags:   map[int32]bool{7: true, -7: true},
+		Friends: FriendSet{"x": {}},
+	}
+	bts, err := in.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out SetHolder
+	if _, err = out.UnmarshalMsg(bts); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("got %+v, want %+v", out, in)
+	}
+
+	// a set is an array of sorted keys, like C# HashSet<T>
+	var tags []string
+	sz, o, err := msgp.ReadArrayHeaderBytes(bts[1:]) // skip the struct header
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := uint32(0); i < sz; i++ {
+		var s string
+		if s, o, err = msgp.ReadStringBytes(o); err != nil {
+			t.Fatal(err)
+		}
+		tags = append(tags, s)
+	}
+	if !reflect.DeepEqual(tags, []string{"a", "b", "c"}) {
+		t.Errorf("tags written as %v", tags)
+	}
+
+	// the encoding is stable
+	for i := 0; i < 10; i++ {
+		again, err := in.MarshalMsg(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(again, bts) {
+			t.Fatal("set encoding is not deterministic")
+		}
+	}
+}
+
+// Keys of a map[K]bool set with a false value are not in the set.
+func TestBoolSetFalseKeys(t *testing.T) {
+	in := SetHolder{Flags: map[int32]bool{1: true, 2: false, 3: false}}
+	bts, err := in.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := in.ExactMsgsize(); n != len(bts) {
+		t.Errorf("ExactMsgsize() = %d, wrote %d bytes", n, len(bts))
+	}
+	if n := in.Msgsize(); n < len(bts) {
+		t.Errorf("Msgsize() = %d, wrote %d bytes", n, len(bts))
+	}
+	var buf bytes.Buffer
+	if err = msgp.Encode(&buf, &in); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), bts) {
+		t.Error("EncodeMsg and MarshalMsg differ")
+	}
+
+	var out SetHolder
+	if _, err = out.UnmarshalMsg(bts); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[int32]bool{1: true}; !reflect.DeepEqual(out.Flags, want) {
+		t.Errorf("Flags = %v, want %v", out.Flags, want)
+	}
+}
@@ -0,0 +1,9 @@
+package _generated
+
+//go:generate csmsgp2go -io
+
+type SortedField struct {
+	Sorted   map[string]int32   `msg:"0,sorted"`
+	Unsorted map[string]int32   `msg:"1"`
+	Groups   []map[int16]string `msg:"2,sorted"`
+}
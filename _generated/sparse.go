@@ -0,0 +1,20 @@
+package _generated
+
+//go:generate csmsgp2go -io -exactsize
+
+type Sparse struct {
+	A     int32  `msg:"0"`
+	B     string `msg:"3"`
+	C     bool   `msg:"300,peek"`
+	D     uint16 `msg:"1000"`
+	Inner struct {
+		X int8 `msg:"2"`
+		Y int8 `msg:"40"`
+	} `msg:"1001"`
+}
+
+// SparseSmall is declared out of index order.
+type SparseSmall struct {
+	Second int32 `msg:"1"`
+	First  int32 `msg:"0"`
+}
@@ -0,0 +1,98 @@
+package _generated
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestSparseIndexes(t *testing.T) {
+	in := Sparse{A: 1, B: "b", C: true, D: 7}
+	in.Inner.X, in.Inner.Y = 2, 3
+	bts, err := in.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := in.ExactMsgsize(); n != len(bts) {
+		t.Errorf("ExactMsgsize() = %d, want %d", n, len(bts))
+	}
+	if n := in.Msgsize(); n < len(bts) {
+		t.Errorf("Msgsize() = %d, less than %d", n, len(bts))
+	}
+
+	// 1002 elements; every unused index is a nil
+	arr, _, err := msgp.ReadIntfBytes(bts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	elems := arr.([]interface{})
+	if len(elems) != 1002 {
+		t.Fatalf("len = %d, want 1002", len(elems))
+	}
+	for i, v := range elems {
+		switch i {
+		case 0, 3, 300, 1000, 1001:
+			if v == nil {
+				t.Errorf("index %d is nil", i)
+			}
+		default:
+			if v != nil {
+				t.Errorf("index %d = %v, want nil", i, v)
+			}
+		}
+	}
+	if inner := elems[1001].([]interface{}); len(inner) != 41 || inner[2] != int64(2) || inner[40] != int64(3) {
+		t.Errorf("Inner = %v", inner)
+	}
+
+	var out Sparse
+	if _, err = out.UnmarshalMsg(bts); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("UnmarshalMsg() = %+v, want %+v", out, in)
+	}
+	if c, err := PeekSparseC(bts); err != nil || !c {
+		t.Errorf("PeekSparseC() = %v, %v", c, err)
+	}
+
+	var buf bytes.Buffer
+	if err = msgp.Encode(&buf, &in); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), bts) {
+		t.Errorf("EncodeMsg() differs from MarshalMsg()")
+	}
+	out = Sparse{}
+	if err = msgp.Decode(&buf, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("DecodeMsg() = %+v, want %+v", out, in)
+	}
+}
+
+func TestSparseSkipsUnusedIndexes(t *testing.T) {
+	// values the Go side does not know are skipped
+	bts := msgp.AppendArrayHeader(nil, 2)
+	bts = msgp.AppendInt32(bts, 5)
+	bts = msgp.AppendInt32(bts, 6)
+	var s SparseSmall
+	if _, err := s.UnmarshalMsg(bts); err != nil {
+		t.Fatal(err)
+	}
+	if s.First != 5 || s.Second != 6 {
+		t.Errorf("SparseSmall = %+v, want fields in index order", s)
+	}
+
+	bts = msgp.AppendArrayHeader(nil, 1002)
+	for i := 0; i < 1002; i++ {
+		bts = msgp.AppendString(bts, "unknown")
+	}
+	var out Sparse
+	if _, err := out.UnmarshalMsg(bts); err == nil {
+		t.Error("expected an error for a string in an int field")
+	}
+}
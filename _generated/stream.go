@@ -0,0 +1,13 @@
+package _generated
+
+//go:generate csmsgp2go -stream
+
+type StreamItem struct {
+	ID    int64  `msg:"0"`
+	Name  string `msg:"1"`
+	Count int32  `msg:"2"`
+}
+
+type StreamItems []StreamItem
+
+type StreamIDs []int64
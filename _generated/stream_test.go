@@ -0,0 +1,66 @@
+package _generated
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestStreamItemsEach(t *testing.T) {
+	var buf bytes.Buffer
+	en := msgp.NewWriter(&buf)
+	var next int64
+	err := EncodeStreamItemsEach(en, 100, func(el *StreamItem) error {
+		next++
+		el.ID = next
+		el.Count = int32(next * 2)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = en.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the streamed form must be identical to the slice form
+	var all StreamItems
+	if _, err = all.UnmarshalMsg(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 100 || all[99].ID != 100 {
+		t.Fatalf("unexpected slice: %d elements", len(all))
+	}
+
+	var sum int64
+	err = DecodeStreamItemsEach(msgp.NewReader(&buf), func(el *StreamItem) error {
+		sum += el.ID
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum != 5050 {
+		t.Errorf("sum = %d, want 5050", sum)
+	}
+}
+
+func TestStreamIDsEachStops(t *testing.T) {
+	bts, err := StreamIDs{1, 2, 3}.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := msgp.ErrShortBytes
+	var seen int
+	err = DecodeStreamIDsEach(msgp.NewReader(bytes.NewReader(bts)), func(id *int64) error {
+		seen++
+		if *id == 2 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || seen != 2 {
+		t.Errorf("got err=%v after %d elements", err, seen)
+	}
+}
@@ -0,0 +1,12 @@
+package _generated
+
+import "time"
+
+//go:generate csmsgp2go -io -exactsize
+
+type TimeSpanFields struct {
+	Cooldown time.Duration            `msg:"0,timespan"`
+	Steps    []time.Duration          `msg:"1,timespan"`
+	ByName   map[string]time.Duration `msg:"2,timespan"`
+	Nanos    time.Duration            `msg:"3"`
+}
@@ -0,0 +1,12 @@
+package _generated
+
+import "time"
+
+//go:generate csmsgp2go -io
+
+//msgp:timespan
+
+type TimeSpanFile struct {
+	Timeout time.Duration   `msg:"0"`
+	Retries []time.Duration `msg:"1"`
+}
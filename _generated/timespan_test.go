@@ -0,0 +1,56 @@
+package _generated
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aggronmagi/csmsgp2go/csmsgp"
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestTimeSpanField(t *testing.T) {
+	in := TimeSpanFields{
+		Cooldown: 1500 * time.Millisecond,
+		Steps:    []time.Duration{time.Second, -time.Minute},
+		ByName:   map[string]time.Duration{"a": time.Hour},
+		Nanos:    7,
+	}
+	bts, err := in.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := in.ExactMsgsize(); n != len(bts) {
+		t.Errorf("ExactMsgsize() = %d, want %d", n, len(bts))
+	}
+	// TimeSpan ticks, as written by MessagePack-CSharp
+	ticks, _, err := msgp.ReadInt64Bytes(bts[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticks != 15000000 {
+		t.Errorf("Cooldown written as %d ticks, want 15000000", ticks)
+	}
+	var out TimeSpanFields
+	if _, err = out.UnmarshalMsg(bts); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("got %+v, want %+v", out, in)
+	}
+}
+
+func TestTimeSpanOverflow(t *testing.T) {
+	o := msgp.AppendArrayHeader(nil, 2)
+	o = msgp.AppendInt64(o, 1<<62) // about 14600 years
+	o = msgp.AppendArrayHeader(o, 0)
+	var v TimeSpanFile
+	_, err := v.UnmarshalMsg(o)
+	if msgp.Cause(err) != csmsgp.ErrTimeSpanOverflow {
+		t.Errorf("UnmarshalMsg error = %v, want ErrTimeSpanOverflow", err)
+	}
+	if err = msgp.Decode(bytes.NewReader(o), &v); msgp.Cause(err) != csmsgp.ErrTimeSpanOverflow {
+		t.Errorf("DecodeMsg error = %v, want ErrTimeSpanOverflow", err)
+	}
+}
@@ -0,0 +1,64 @@
+package _generated
+
+//go:generate csmsgp2go -io
+
+//msgp:maxlen 64
+
+// Quoted values may hold spaces.
+//msgp:ignore ScoreTable
+//msgp:replace ScoreTable with:"map[string] int32"
+
+// LimitedBag accepts at most 4 items or scores.
+//
+//msgp:maxlen 4
+//msgp:deterministic
+type LimitedBag struct {
+	Items  []int32          `msg:"0"`
+	Scores map[string]int32 `msg:"1"`
+}
+
+// DefaultBag uses the file limit of 64.
+type DefaultBag struct {
+	Items  []int32    `msg:"0"`
+	Scores ScoreTable `msg:"1"`
+}
+
+// ScoreTable is encoded as the map it replaces.
+type ScoreTable map[string]int32
+
+type (
+	// CompactBag writes float64 values as float32
+	// when that loses no precision.
+	//
+	//msgp:compactfloats
+	CompactBag struct {
+		Values []float64 `msg:"0"`
+	}
+
+	// WideBag writes all float64 values as float64.
+	WideBag struct {
+		Values []float64 `msg:"0"`
+	}
+)
+
+// Types with their own directives keep them when used
+// in other types.
+
+// ShortScores accepts at most 2 scores.
+//
+//msgp:maxlen 2
+type ShortScores map[string]int32
+
+// CompactPoint writes its coordinates as float32
+// when that loses no precision.
+//
+//msgp:compactfloats
+type CompactPoint struct {
+	X float64 `msg:"0"`
+	Y float64 `msg:"1"`
+}
+
+type NestedBag struct {
+	Scores ShortScores  `msg:"0"`
+	Point  CompactPoint `msg:"1"`
+}
@@ -0,0 +1,125 @@
+package _generated
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aggronmagi/csmsgp2go/csmsgp"
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestTypeMaxlen(t *testing.T) {
+	items := func(n int) []int32 { return make([]int32, n) }
+	for _, tc := range []struct {
+		in  msgp.Marshaler
+		out msgp.Unmarshaler
+		max uint32
+	}{
+		{&LimitedBag{Items: items(5)}, &LimitedBag{}, 4},
+		{&LimitedBag{Scores: map[string]int32{"a": 1, "b": 2, "c": 3, "d": 4, "e": 5}}, &LimitedBag{}, 4},
+		{&DefaultBag{Items: items(65)}, &DefaultBag{}, 64},
+	} {
+		bts, err := tc.in.MarshalMsg(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = tc.out.UnmarshalMsg(bts)
+		var le csmsgp.LengthError
+		if !errors.As(err, &le) || le.Max != tc.max {
+			t.Errorf("UnmarshalMsg(%T) error = %v; want limit %d", tc.in, err, tc.max)
+		}
+		err = msgp.Decode(bytes.NewReader(bts), tc.out.(msgp.Decodable))
+		if !errors.As(err, &le) || le.Max != tc.max {
+			t.Errorf("DecodeMsg(%T) error = %v; want limit %d", tc.in, err, tc.max)
+		}
+	}
+
+	// at the limit
+	in := DefaultBag{Items: items(64), Scores: ScoreTable{"a": 1}}
+	bts, err := in.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out DefaultBag
+	if _, err = out.UnmarshalMsg(bts); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Items) != 64 || out.Scores["a"] != 1 {
+		t.Errorf("got %d items, scores %v", len(out.Items), out.Scores)
+	}
+}
+
+func TestTypeDeterministic(t *testing.T) {
+	in := LimitedBag{Scores: make(map[string]int32)}
+	for i := 0; i < 4; i++ {
+		in.Scores[fmt.Sprint("k", 3-i)] = int32(i)
+	}
+	bts, err := in.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := msgp.Skip(bts[1:]) // struct header, Items
+	if err != nil {
+		t.Fatal(err)
+	}
+	sz, o, err := msgp.ReadMapHeaderBytes(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := uint32(0); i < sz; i++ {
+		var k string
+		if k, o, err = msgp.ReadStringBytes(o); err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprint("k", i); k != want {
+			t.Fatalf("key %d = %q; want %q", i, k, want)
+		}
+		if o, err = msgp.Skip(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTypeCompactFloats(t *testing.T) {
+	c, err := (&CompactBag{Values: []float64{1.5}}).MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := (&WideBag{Values: []float64{1.5}}).MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// array header, array header, float
+	if c[2] != 0xca || w[2] != 0xcb {
+		t.Errorf("CompactBag wrote %x, WideBag wrote %x", c, w)
+	}
+}
+
+func TestTypeDirectivesNested(t *testing.T) {
+	in := NestedBag{Point: CompactPoint{X: 1.5, Y: 2.5}}
+	bts, err := in.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// struct header, empty scores, struct header, float
+	if bts[3] != 0xca {
+		t.Errorf("CompactPoint in NestedBag wrote %x", bts)
+	}
+
+	in.Scores = ShortScores{"a": 1, "b": 2, "c": 3}
+	if bts, err = in.MarshalMsg(nil); err != nil {
+		t.Fatal(err)
+	}
+	var out NestedBag
+	_, err = out.UnmarshalMsg(bts)
+	var le csmsgp.LengthError
+	if !errors.As(err, &le) || le.Max != 2 {
+		t.Errorf("UnmarshalMsg error = %v; want limit 2", err)
+	}
+	err = msgp.Decode(bytes.NewReader(bts), &out)
+	if !errors.As(err, &le) || le.Max != 2 {
+		t.Errorf("DecodeMsg error = %v; want limit 2", err)
+	}
+}
@@ -0,0 +1,15 @@
+package _generated
+
+import "github.com/aggronmagi/csmsgp2go/unity"
+
+//go:generate csmsgp2go -io -exactsize -diff
+
+type UnityTransform struct {
+	Pos    unity.Vector3            `msg:"0"`
+	Rot    unity.Quaternion         `msg:"1"`
+	Tint   unity.Color              `msg:"2"`
+	Area   unity.Rect               `msg:"3"`
+	Box    unity.Bounds             `msg:"4"`
+	UV     []unity.Vector2          `msg:"5"`
+	Points map[string]unity.Vector4 `msg:"6"`
+}
@@ -0,0 +1,68 @@
+package _generated
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aggronmagi/csmsgp2go/unity"
+)
+
+func TestUnityFields(t *testing.T) {
+	in := UnityTransform{
+		Pos:    unity.Vector3{X: 1, Y: 2, Z: 3},
+		Rot:    unity.Quaternion{W: 1},
+		Tint:   unity.Color{R: 1, A: 0.5},
+		Area:   unity.Rect{Width: 10, Height: 20},
+		Box:    unity.Bounds{Size: unity.Vector3{X: 1, Y: 1, Z: 1}},
+		UV:     []unity.Vector2{{X: 0.25, Y: 0.75}},
+		Points: map[string]unity.Vector4{"p": {X: 1, W: 1}},
+	}
+	bts, err := in.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := in.ExactMsgsize(); n != len(bts) {
+		t.Errorf("ExactMsgsize() = %d, want %d", n, len(bts))
+	}
+	var out UnityTransform
+	if _, err = out.UnmarshalMsg(bts); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("got %+v, want %+v", out, in)
+	}
+
+	old := in
+	in.Rot.Y = 1
+	patch, err := in.Diff(&old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = old.ApplyPatch(patch); err != nil {
+		t.Fatal(err)
+	}
+	if old.Rot != in.Rot {
+		t.Errorf("patched Rot = %+v, want %+v", old.Rot, in.Rot)
+	}
+}
+
+func TestUnityAliasedImport(t *testing.T) {
+	in := UnityAliased{
+		Pos: unity.Vector3{X: 1, Y: 2, Z: 3},
+		UV:  []unity.Vector2{{X: 0.25, Y: 0.75}},
+	}
+	bts, err := in.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := in.ExactMsgsize(); n != len(bts) {
+		t.Errorf("ExactMsgsize() = %d, want %d", n, len(bts))
+	}
+	var out UnityAliased
+	if _, err = out.UnmarshalMsg(bts); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("got %+v, want %+v", out, in)
+	}
+}
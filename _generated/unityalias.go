@@ -0,0 +1,11 @@
+package _generated
+
+import u "github.com/aggronmagi/csmsgp2go/unity"
+
+//go:generate csmsgp2go -io -exactsize -diff
+
+// UnityAliased imports the unity package under another name.
+type UnityAliased struct {
+	Pos u.Vector3   `msg:"0"`
+	UV  []u.Vector2 `msg:"1"`
+}
@@ -0,0 +1,128 @@
+package csmsgp
+
+import (
+	"math/big"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// The functions below read and write a big.Int like
+// MessagePack-CSharp writes a System.Numerics.BigInteger:
+// a bin holding BigInteger.ToByteArray(), the shortest
+// two's-complement form in little-endian byte order.
+// Zero is the single byte 0x00. A nil *big.Int is written
+// as zero, as BigInteger is a value type in C#.
+
+// bigIntScratch is the size of the stack buffers used
+// for the bytes of a big.Int; larger values allocate.
+const bigIntScratch = 64
+
+var bigOne = big.NewInt(1)
+
+// bigIntLen returns the length of the two's-complement
+// bytes of x.
+func bigIntLen(x *big.Int) int {
+	if x == nil {
+		return 1
+	}
+	bits := x.BitLen()
+	if x.Sign() < 0 && x.TrailingZeroBits() == uint(bits-1) {
+		// -2^n needs one bit less than its magnitude
+		bits--
+	}
+	// one extra bit for the sign
+	return bits/8 + 1
+}
+
+// BigIntSize returns the encoded size of x.
+func BigIntSize(x *big.Int) int {
+	return BytesSize(bigIntLen(x))
+}
+
+// AppendBigInt appends x to b as a C# BigInteger.
+func AppendBigInt(b []byte, x *big.Int) []byte {
+	n := bigIntLen(x)
+	b = msgp.AppendBytesHeader(b, uint32(n))
+	l := len(b)
+	b = append(b, make([]byte, n)...)
+	putBigInt(b[l:], x)
+	return b
+}
+
+// putBigInt writes the little-endian two's-complement
+// bytes of x to dst, which is bigIntLen(x) long.
+func putBigInt(dst []byte, x *big.Int) {
+	if x == nil || x.Sign() == 0 {
+		dst[0] = 0
+		return
+	}
+	x.FillBytes(dst) // big-endian magnitude
+	if x.Sign() < 0 {
+		// negate: invert and add one
+		carry := true
+		for i := len(dst) - 1; i >= 0; i-- {
+			dst[i] = ^dst[i]
+			if carry {
+				dst[i]++
+				carry = dst[i] == 0
+			}
+		}
+	}
+	reverse(dst)
+}
+
+// setBigInt sets x to the little-endian two's-complement
+// number in src. src is modified.
+func setBigInt(x *big.Int, src []byte) {
+	if len(src) == 0 {
+		x.SetInt64(0)
+		return
+	}
+	neg := src[len(src)-1]&0x80 != 0
+	reverse(src)
+	if !neg {
+		x.SetBytes(src)
+		return
+	}
+	// x = -(^src + 1)
+	for i := range src {
+		src[i] = ^src[i]
+	}
+	x.SetBytes(src)
+	x.Add(x, bigOne)
+	x.Neg(x)
+}
+
+func reverse(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
+
+// ReadBigIntBytes reads a C# BigInteger from b into x.
+func ReadBigIntBytes(b []byte, x *big.Int) ([]byte, error) {
+	v, o, err := msgp.ReadBytesZC(b)
+	if err != nil {
+		return b, err
+	}
+	var scratch [bigIntScratch]byte
+	setBigInt(x, append(scratch[:0], v...))
+	return o, nil
+}
+
+// WriteBigInt writes x to w as a C# BigInteger.
+func WriteBigInt(w *msgp.Writer, x *big.Int) error {
+	var scratch [bigIntScratch]byte
+	return w.Append(AppendBigInt(scratch[:0], x)...)
+}
+
+// ReadBigInt reads a C# BigInteger from r into x.
+func ReadBigInt(r *msgp.Reader, x *big.Int) error {
+	var scratch [bigIntScratch]byte
+	v, err := r.ReadBytes(scratch[:0])
+	if err != nil {
+		return err
+	}
+	setBigInt(x, v)
+	return nil
+}
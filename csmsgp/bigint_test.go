@@ -0,0 +1,79 @@
+package csmsgp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestBigIntWireFormat(t *testing.T) {
+	// BigInteger.ToByteArray() results from .NET
+	for _, tc := range []struct {
+		n   string
+		hex string
+	}{
+		{"0", "00"},
+		{"1", "01"},
+		{"127", "7f"},
+		{"128", "8000"},
+		{"255", "ff00"},
+		{"256", "0001"},
+		{"-1", "ff"},
+		{"-128", "80"},
+		{"-129", "7fff"},
+		{"-256", "00ff"},
+		{"-32768", "0080"},
+		{"18446744073709551616", "000000000000000001"},
+	} {
+		x, _ := new(big.Int).SetString(tc.n, 10)
+		want, _ := hex.DecodeString(tc.hex)
+		bts := AppendBigInt(nil, x)
+		if !bytes.Equal(bts, msgp.AppendBytes(nil, want)) {
+			t.Errorf("AppendBigInt(%s) = %x, want bin %s", tc.n, bts, tc.hex)
+		}
+		if n := BigIntSize(x); n != len(bts) {
+			t.Errorf("BigIntSize(%s) = %d, want %d", tc.n, n, len(bts))
+		}
+		var y big.Int
+		if _, err := ReadBigIntBytes(bts, &y); err != nil || y.Cmp(x) != 0 {
+			t.Errorf("ReadBigIntBytes(%x) = %s, %v; want %s", bts, &y, err, tc.n)
+		}
+	}
+	if bts := AppendBigInt(nil, nil); !bytes.Equal(bts, []byte{0xc4, 1, 0}) {
+		t.Errorf("AppendBigInt(nil) = %x", bts)
+	}
+}
+
+func TestBigIntRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var buf bytes.Buffer
+	w := msgp.NewWriter(&buf)
+	var want []*big.Int
+	for i := 0; i < 500; i++ {
+		x := new(big.Int).Rand(rng, new(big.Int).Lsh(bigOne, uint(rng.Intn(700))))
+		if rng.Intn(2) == 0 {
+			x.Neg(x)
+		}
+		want = append(want, x)
+		if err := WriteBigInt(w, x); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	r := msgp.NewReader(&buf)
+	for _, x := range want {
+		var y big.Int
+		if err := ReadBigInt(r, &y); err != nil {
+			t.Fatal(err)
+		}
+		if y.Cmp(x) != 0 {
+			t.Fatalf("ReadBigInt() = %s, want %s", &y, x)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package csmsgp
+
+import (
+	"time"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// DateTimeOffset is a time.Time that keeps its UTC offset
+// on the wire, like C# DateTimeOffset. MessagePack-CSharp
+// writes it as
+//
+//	[DateTime, offsetMinutes int16]
+//
+// where DateTime is the wall-clock time at that offset,
+// written as a msgpack timestamp (extension -1).
+// Decoded values are in a time.FixedZone with the offset.
+// Offsets are whole minutes; extra seconds are dropped.
+type DateTimeOffset struct {
+	time.Time
+}
+
+// DateTimeOffsetSize is the upper bound of the encoded size.
+const DateTimeOffsetSize = 1 + msgp.TimeSize + msgp.Int16Size
+
+// parts returns the wall-clock time as UTC and the offset.
+func (d *DateTimeOffset) parts() (time.Time, int16) {
+	_, off := d.Zone()
+	minutes := int16(off / 60)
+	return d.Add(time.Duration(minutes) * time.Minute).UTC(), minutes
+}
+
+func (d *DateTimeOffset) set(wall time.Time, minutes int16) {
+	zone := time.FixedZone("", int(minutes)*60)
+	d.Time = wall.Add(-time.Duration(minutes) * time.Minute).In(zone)
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (d *DateTimeOffset) MarshalMsg(b []byte) (o []byte, err error) {
+	wall, minutes := d.parts()
+	o = msgp.AppendArrayHeader(b, 2)
+	o = msgp.AppendTimeExt(o, wall)
+	o = msgp.AppendInt16(o, minutes)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (d *DateTimeOffset) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var sz uint32
+	sz, bts, err = msgp.ReadArrayHeaderBytes(bts)
+	if err != nil {
+		return
+	}
+	if sz != 2 {
+		err = msgp.ArrayError{Wanted: 2, Got: sz}
+		return
+	}
+	var wall time.Time
+	wall, bts, err = msgp.ReadTimeBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err, "DateTime")
+		return
+	}
+	var minutes int16
+	minutes, bts, err = msgp.ReadInt16Bytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err, "Offset")
+		return
+	}
+	d.set(wall, minutes)
+	o = bts
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (d *DateTimeOffset) EncodeMsg(en *msgp.Writer) (err error) {
+	wall, minutes := d.parts()
+	err = en.WriteArrayHeader(2)
+	if err != nil {
+		return
+	}
+	err = en.WriteTimeExt(wall)
+	if err != nil {
+		return
+	}
+	return en.WriteInt16(minutes)
+}
+
+// DecodeMsg implements msgp.Decodable
+func (d *DateTimeOffset) DecodeMsg(dc *msgp.Reader) (err error) {
+	var sz uint32
+	sz, err = dc.ReadArrayHeader()
+	if err != nil {
+		return
+	}
+	if sz != 2 {
+		return msgp.ArrayError{Wanted: 2, Got: sz}
+	}
+	wall, err := dc.ReadTime()
+	if err != nil {
+		return msgp.WrapError(err, "DateTime")
+	}
+	minutes, err := dc.ReadInt16()
+	if err != nil {
+		return msgp.WrapError(err, "Offset")
+	}
+	d.set(wall, minutes)
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (d *DateTimeOffset) Msgsize() int { return DateTimeOffsetSize }
+
+// ExactMsgsize returns the exact number of bytes occupied by the serialized message
+func (d *DateTimeOffset) ExactMsgsize() int {
+	wall, minutes := d.parts()
+	return 1 + TimeExtSize(wall) + IntSize(int64(minutes))
+}
@@ -0,0 +1,53 @@
+package csmsgp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestDateTimeOffset(t *testing.T) {
+	zone := time.FixedZone("", 9*60*60)
+	in := DateTimeOffset{time.Date(2024, 5, 6, 7, 8, 9, 0, zone)}
+	bts, err := in.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// [2024-05-06T07:08:09 as a UTC timestamp, 540 minutes]
+	want, _ := hex.DecodeString("92" + "d6ff" + "663881d9" + "d1021c")
+	if !bytes.Equal(bts, want) {
+		t.Errorf("encoded as %x, want %x", bts, want)
+	}
+	if n := in.ExactMsgsize(); n != len(bts) {
+		t.Errorf("ExactMsgsize() = %d, want %d", n, len(bts))
+	}
+
+	var out DateTimeOffset
+	if _, err = out.UnmarshalMsg(bts); err != nil {
+		t.Fatal(err)
+	}
+	if !out.Equal(in.Time) {
+		t.Errorf("decoded %v, want %v", out, in)
+	}
+	if _, off := out.Zone(); off != 9*60*60 {
+		t.Errorf("decoded offset %d, want %d", off, 9*60*60)
+	}
+
+	var buf bytes.Buffer
+	if err = msgp.Encode(&buf, &in); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), bts) {
+		t.Errorf("EncodeMsg wrote %x, want %x", buf.Bytes(), bts)
+	}
+	var dec DateTimeOffset
+	if err = msgp.Decode(&buf, &dec); err != nil {
+		t.Fatal(err)
+	}
+	if !dec.Equal(in.Time) || dec.Format(time.RFC3339) != "2024-05-06T07:08:09+09:00" {
+		t.Errorf("decoded %v, want %v", dec, in)
+	}
+}
@@ -0,0 +1,131 @@
+package csmsgp
+
+import (
+	"encoding"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// Fill sets the value v points to so that its encoding
+// crosses the MessagePack size classes at n: strings have
+// n bytes, slices and maps up to 16 elements (fewer when
+// nested), and integers are n or -n. Generated ExactMsgsize
+// tests call it with n on either side of each boundary.
+//
+// Interfaces, msgp.Raw, unexported fields, pointers other
+// than *big.Int, and types with MarshalText or MarshalBinary
+// methods, whose values may need to be valid, are left alone.
+func Fill(v interface{}, n int) {
+	fill(reflect.ValueOf(v).Elem(), n, 0)
+}
+
+// fillMaxLen is the length of filled collections; 16
+// is the first length past the fixarray and fixmap range.
+const fillMaxLen = 16
+
+var (
+	rawType  = reflect.TypeOf(msgp.Raw(nil))
+	textType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	binType  = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
+	timeType = reflect.TypeOf(time.Time{})
+	bigType  = reflect.TypeOf((*big.Int)(nil))
+)
+
+func fill(v reflect.Value, n, depth int) {
+	if !v.CanSet() {
+		return
+	}
+	switch v.Type() {
+	case rawType:
+		return
+	case timeType:
+		v.Set(reflect.ValueOf(time.Unix(int64(n)*3600, int64(n)).UTC()))
+		return
+	case bigType:
+		v.Set(reflect.ValueOf(big.NewInt(-int64(n) << 40)))
+		return
+	}
+	if pt := reflect.PointerTo(v.Type()); pt.Implements(textType) || pt.Implements(binType) {
+		return
+	}
+	switch v.Kind() {
+	case reflect.String:
+		ln := n
+		if depth > 0 && ln > 256 {
+			ln = 256
+		}
+		v.SetString(strings.Repeat("x", ln))
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := int64(n)
+		if n%2 == 1 {
+			i = -i
+		}
+		for v.OverflowInt(i) {
+			i /= 2
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u := uint64(n)
+		for v.OverflowUint(u) {
+			u /= 2
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(float64(n) + 0.5)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			fill(v.Field(i), n, depth)
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			fill(v.Index(i), n, depth+1)
+		}
+	case reflect.Slice:
+		s := reflect.MakeSlice(v.Type(), fillLen(n, depth), fillLen(n, depth))
+		for i := 0; i < s.Len(); i++ {
+			fill(s.Index(i), n, depth+1)
+		}
+		v.Set(s)
+	case reflect.Map:
+		m := reflect.MakeMap(v.Type())
+		for i := 0; i < fillLen(n, depth); i++ {
+			k := reflect.New(v.Type().Key()).Elem()
+			fillKey(k, i)
+			e := reflect.New(v.Type().Elem()).Elem()
+			fill(e, n, depth+1)
+			m.SetMapIndex(k, e)
+		}
+		v.Set(m)
+	}
+}
+
+// fillLen returns the length of a collection at depth.
+func fillLen(n, depth int) int {
+	max := fillMaxLen
+	if depth > 0 {
+		max = 2
+	}
+	if n < max {
+		return n
+	}
+	return max
+}
+
+// fillKey sets the map key k to a value distinct for each i.
+func fillKey(k reflect.Value, i int) {
+	switch k.Kind() {
+	case reflect.String:
+		k.SetString("k" + strconv.Itoa(i))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		k.SetInt(int64(i))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		k.SetUint(uint64(i))
+	}
+}
@@ -0,0 +1,46 @@
+package csmsgp
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestFill(t *testing.T) {
+	type inner struct {
+		S []string
+	}
+	var v struct {
+		S   string
+		I8  int8
+		U16 uint16
+		F   float32
+		B   bool
+		L   []inner
+		M   map[int32]bool
+		A   [2]string
+		T   time.Time
+		N   *big.Int
+		R   msgp.Raw
+		Any interface{}
+		p   string
+	}
+	Fill(&v, 255)
+	switch {
+	case len(v.S) != 255, v.I8 != -127, v.U16 != 255, v.F != 255.5, !v.B:
+		t.Errorf("scalars: %+v", v)
+	case len(v.L) != 16 || len(v.L[0].S) != 2, len(v.M) != 16 || !v.M[15]:
+		t.Errorf("collections: %d %d", len(v.L), len(v.M))
+	case v.A[1] != v.S, v.T.IsZero(), v.N == nil || v.N.Sign() >= 0:
+		t.Errorf("others: %+v", v)
+	case v.R != nil, v.Any != nil, v.p != "":
+		t.Errorf("filled what should be left alone: %+v", v)
+	}
+
+	Fill(&v, 1)
+	if len(v.S) != 1 || v.I8 != -1 || len(v.L) != 1 {
+		t.Errorf("n=1: %+v", v)
+	}
+}
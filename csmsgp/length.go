@@ -0,0 +1,20 @@
+package csmsgp
+
+import "fmt"
+
+// LengthError is returned by generated decoders when an
+// array or map header is longer than the //msgp:maxlen
+// limit, before anything is allocated for it.
+type LengthError struct {
+	Max uint32 // the limit
+	Got uint32 // the length in the header
+}
+
+// Error implements the error interface.
+func (e LengthError) Error() string {
+	return fmt.Sprintf("msgp: length %d exceeds the limit of %d", e.Got, e.Max)
+}
+
+// Resumable returns false; the elements of the
+// collection have not been read.
+func (e LengthError) Resumable() bool { return false }
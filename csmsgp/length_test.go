@@ -0,0 +1,22 @@
+package csmsgp
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestLengthError(t *testing.T) {
+	err := msgp.WrapError(LengthError{Max: 4, Got: 5}, "Items")
+	var le LengthError
+	if !errors.As(err, &le) || le.Got != 5 {
+		t.Fatalf("errors.As(%v) = %v", err, le)
+	}
+	if msgp.Resumable(err) {
+		t.Error("LengthError is resumable")
+	}
+	if got, want := err.Error(), "msgp: length 5 exceeds the limit of 4 at Items"; got != want {
+		t.Errorf("Error() = %q; want %q", got, want)
+	}
+}
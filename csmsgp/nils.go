@@ -0,0 +1,30 @@
+package csmsgp
+
+import "github.com/tinylib/msgp/msgp"
+
+// nils is a run of MessagePack nils.
+var nils = [32]byte{
+	0xc0, 0xc0, 0xc0, 0xc0, 0xc0, 0xc0, 0xc0, 0xc0,
+	0xc0, 0xc0, 0xc0, 0xc0, 0xc0, 0xc0, 0xc0, 0xc0,
+	0xc0, 0xc0, 0xc0, 0xc0, 0xc0, 0xc0, 0xc0, 0xc0,
+	0xc0, 0xc0, 0xc0, 0xc0, 0xc0, 0xc0, 0xc0, 0xc0,
+}
+
+// AppendNils appends n nils to b. Generated code uses
+// it for long runs of unused struct field indexes.
+func AppendNils(b []byte, n int) []byte {
+	for ; n > len(nils); n -= len(nils) {
+		b = append(b, nils[:]...)
+	}
+	return append(b, nils[:n]...)
+}
+
+// WriteNils writes n nils to w.
+func WriteNils(w *msgp.Writer, n int) error {
+	for ; n > len(nils); n -= len(nils) {
+		if err := w.Append(nils[:]...); err != nil {
+			return err
+		}
+	}
+	return w.Append(nils[:n]...)
+}
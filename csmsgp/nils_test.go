@@ -0,0 +1,28 @@
+package csmsgp
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestNils(t *testing.T) {
+	for _, n := range []int{0, 1, 31, 32, 33, 100} {
+		want := bytes.Repeat([]byte{0xc0}, n)
+		if got := AppendNils([]byte{1}, n); !bytes.Equal(got[1:], want) || got[0] != 1 {
+			t.Errorf("AppendNils(%d) = %x", n, got)
+		}
+		var buf bytes.Buffer
+		w := msgp.NewWriter(&buf)
+		if err := WriteNils(w, n); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("WriteNils(%d) = %x", n, buf.Bytes())
+		}
+	}
+}
@@ -0,0 +1,182 @@
+// Package csmsgp contains the runtime helpers used by
+// code generated with csmsgp2go.
+//
+// Generated files only import this package when they
+// need one of its helpers; plain Marshal/Unmarshal code
+// depends on github.com/tinylib/msgp/msgp alone.
+package csmsgp
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// The functions below return the exact number of bytes
+// that the matching msgp.AppendXxx function writes for
+// the given value. They are used by generated
+// ExactMsgsize methods.
+
+// ArrayHeaderSize returns the encoded size of an array header.
+func ArrayHeaderSize(sz uint32) int {
+	switch {
+	case sz <= 15:
+		return 1
+	case sz <= math.MaxUint16:
+		return 3
+	default:
+		return 5
+	}
+}
+
+// MapHeaderSize returns the encoded size of a map header.
+func MapHeaderSize(sz uint32) int {
+	// map and array headers share the same layout
+	return ArrayHeaderSize(sz)
+}
+
+// StringSize returns the encoded size of a string of length n.
+func StringSize(n int) int {
+	switch {
+	case n <= 31:
+		return 1 + n
+	case n <= math.MaxUint8:
+		return 2 + n
+	case n <= math.MaxUint16:
+		return 3 + n
+	default:
+		return 5 + n
+	}
+}
+
+// BytesSize returns the encoded size of a bin of length n.
+func BytesSize(n int) int {
+	switch {
+	case n <= math.MaxUint8:
+		return 2 + n
+	case n <= math.MaxUint16:
+		return 3 + n
+	default:
+		return 5 + n
+	}
+}
+
+// IntSize returns the encoded size of a signed integer.
+func IntSize(i int64) int {
+	if i >= 0 {
+		switch {
+		case i <= math.MaxInt8:
+			return 1
+		case i <= math.MaxInt16:
+			return 3
+		case i <= math.MaxInt32:
+			return 5
+		default:
+			return 9
+		}
+	}
+	switch {
+	case i >= -32:
+		return 1
+	case i >= math.MinInt8:
+		return 2
+	case i >= math.MinInt16:
+		return 3
+	case i >= math.MinInt32:
+		return 5
+	default:
+		return 9
+	}
+}
+
+// UintSize returns the encoded size of an unsigned integer.
+func UintSize(u uint64) int {
+	switch {
+	case u <= math.MaxInt8:
+		return 1
+	case u <= math.MaxUint8:
+		return 2
+	case u <= math.MaxUint16:
+		return 3
+	case u <= math.MaxUint32:
+		return 5
+	default:
+		return 9
+	}
+}
+
+// FloatSize returns the encoded size of f as written
+// by msgp.AppendFloat (compact floats).
+func FloatSize(f float64) int {
+	if float64(float32(f)) == f {
+		return msgp.Float32Size
+	}
+	return msgp.Float64Size
+}
+
+// TimeExtSize returns the encoded size of t as written
+// by msgp.AppendTimeExt.
+func TimeExtSize(t time.Time) int {
+	secPrec := t.Truncate(time.Second)
+	remain := t.Sub(secPrec).Nanoseconds()
+	asSecs := secPrec.Unix()
+	switch {
+	case remain == 0 && asSecs > 0 && asSecs <= math.MaxUint32:
+		return 2 + 4
+	case asSecs < 0 || asSecs >= (1<<34):
+		return 3 + 12
+	default:
+		return 2 + 8
+	}
+}
+
+// ExtensionSize returns the encoded size of an extension
+// whose body is n bytes long.
+func ExtensionSize(n int) int {
+	switch n {
+	case 0:
+		return 3
+	case 1, 2, 4, 8, 16:
+		return 2 + n
+	}
+	switch {
+	case n < math.MaxUint8:
+		return 3 + n
+	case n < math.MaxUint16:
+		return 4 + n
+	default:
+		return 6 + n
+	}
+}
+
+// IntfSize returns the encoded size of an arbitrary value.
+// The value is encoded to find out; invalid values
+// report the size of a nil.
+func IntfSize(i interface{}) int {
+	o, err := msgp.AppendIntf(nil, i)
+	if err != nil {
+		return msgp.NilSize
+	}
+	return len(o)
+}
+
+// JSONNumberSize returns the encoded size of n.
+func JSONNumberSize(n json.Number) int {
+	o, err := msgp.AppendJSONNumber(nil, n)
+	if err != nil {
+		return msgp.NilSize
+	}
+	return len(o)
+}
+
+// MarshaledSize returns the encoded size of a value
+// that has no ExactMsgsize method by marshaling it.
+func MarshaledSize(m msgp.Marshaler) int {
+	o, err := m.MarshalMsg(nil)
+	if err != nil {
+		return 0
+	}
+	return len(o)
+}
@@ -0,0 +1,73 @@
+package csmsgp
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestIntSize(t *testing.T) {
+	for _, i := range []int64{
+		0, 1, 127, 128, 255, 256, math.MaxInt16, math.MaxInt16 + 1,
+		math.MaxInt32, math.MaxInt32 + 1, math.MaxInt64,
+		-1, -32, -33, math.MinInt8, math.MinInt8 - 1, math.MinInt16,
+		math.MinInt16 - 1, math.MinInt32, math.MinInt32 - 1, math.MinInt64,
+	} {
+		if got, want := IntSize(i), len(msgp.AppendInt64(nil, i)); got != want {
+			t.Errorf("IntSize(%d) = %d, want %d", i, got, want)
+		}
+	}
+}
+
+func TestUintSize(t *testing.T) {
+	for _, u := range []uint64{
+		0, 127, 128, 255, 256, math.MaxUint16, math.MaxUint16 + 1,
+		math.MaxUint32, math.MaxUint32 + 1, math.MaxUint64,
+	} {
+		if got, want := UintSize(u), len(msgp.AppendUint64(nil, u)); got != want {
+			t.Errorf("UintSize(%d) = %d, want %d", u, got, want)
+		}
+	}
+}
+
+func TestHeaderAndLengthSizes(t *testing.T) {
+	for _, n := range []int{0, 1, 15, 16, 31, 32, 255, 256, math.MaxUint16, math.MaxUint16 + 1} {
+		if got, want := ArrayHeaderSize(uint32(n)), len(msgp.AppendArrayHeader(nil, uint32(n))); got != want {
+			t.Errorf("ArrayHeaderSize(%d) = %d, want %d", n, got, want)
+		}
+		if got, want := MapHeaderSize(uint32(n)), len(msgp.AppendMapHeader(nil, uint32(n))); got != want {
+			t.Errorf("MapHeaderSize(%d) = %d, want %d", n, got, want)
+		}
+		if got, want := StringSize(n), len(msgp.AppendString(nil, string(make([]byte, n)))); got != want {
+			t.Errorf("StringSize(%d) = %d, want %d", n, got, want)
+		}
+		if got, want := BytesSize(n), len(msgp.AppendBytes(nil, make([]byte, n))); got != want {
+			t.Errorf("BytesSize(%d) = %d, want %d", n, got, want)
+		}
+		raw := msgp.RawExtension{Type: 10, Data: make([]byte, n)}
+		ext, err := msgp.AppendExtension(nil, &raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ExtensionSize(n); got != len(ext) {
+			t.Errorf("ExtensionSize(%d) = %d, want %d", n, got, len(ext))
+		}
+	}
+}
+
+func TestFloatAndTimeSizes(t *testing.T) {
+	for _, f := range []float64{0, 1.5, math.Pi, math.MaxFloat64} {
+		if got, want := FloatSize(f), len(msgp.AppendFloat(nil, f)); got != want {
+			t.Errorf("FloatSize(%v) = %d, want %d", f, got, want)
+		}
+	}
+	for _, tm := range []time.Time{
+		time.Unix(1, 0), time.Unix(1, 5), time.Unix(-1, 0), time.Unix(1<<34, 0),
+	} {
+		if got, want := TimeExtSize(tm), len(msgp.AppendTimeExt(nil, tm)); got != want {
+			t.Errorf("TimeExtSize(%v) = %d, want %d", tm, got, want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package csmsgp
+
+// Ordered is the set of map key types that can be written
+// in sorted order.
+type Ordered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~string
+}
+
+// SortedKeys appends the keys of m to dst in ascending
+// order and returns the extended slice. Generated code
+// passes a small stack array as dst, so sorting the keys
+// of a small map does not allocate.
+func SortedKeys[K Ordered, V any](dst []K, m map[K]V) []K {
+	for k := range m {
+		dst = append(dst, k)
+	}
+	sortOrdered(dst)
+	return dst
+}
+
+// SetKeys is like SortedKeys for a set kept as a map[K]bool:
+// only the keys whose value is true are in the set, and only
+// they are appended.
+func SetKeys[K Ordered, V ~bool](dst []K, m map[K]V) []K {
+	for k, v := range m {
+		if v {
+			dst = append(dst, k)
+		}
+	}
+	sortOrdered(dst)
+	return dst
+}
+
+// SetLen returns the number of keys in the set m holds,
+// those whose value is true.
+func SetLen[K comparable, V ~bool](m map[K]V) int {
+	n := 0
+	for _, v := range m {
+		if v {
+			n++
+		}
+	}
+	return n
+}
+
+// sortOrdered sorts s in place without going through
+// sort.Interface, which would make s escape to the heap.
+func sortOrdered[K Ordered](s []K) {
+	if len(s) <= 12 {
+		for i := 1; i < len(s); i++ {
+			for j := i; j > 0 && s[j] < s[j-1]; j-- {
+				s[j], s[j-1] = s[j-1], s[j]
+			}
+		}
+		return
+	}
+	// heapsort
+	for i := len(s)/2 - 1; i >= 0; i-- {
+		siftDown(s, i, len(s))
+	}
+	for i := len(s) - 1; i > 0; i-- {
+		s[0], s[i] = s[i], s[0]
+		siftDown(s, 0, i)
+	}
+}
+
+func siftDown[K Ordered](s []K, root, n int) {
+	for {
+		child := 2*root + 1
+		if child >= n {
+			return
+		}
+		if child+1 < n && s[child] < s[child+1] {
+			child++
+		}
+		if !(s[root] < s[child]) {
+			return
+		}
+		s[root], s[child] = s[child], s[root]
+		root = child
+	}
+}
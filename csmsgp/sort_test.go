@@ -0,0 +1,53 @@
+package csmsgp
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSortedKeys(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 12, 13, 100} {
+		m := make(map[int32]struct{}, n)
+		for len(m) < n {
+			m[rand.Int31()-1<<30] = struct{}{}
+		}
+		var buf [8]int32
+		keys := SortedKeys(buf[:0], m)
+		if len(keys) != n {
+			t.Fatalf("got %d keys, want %d", len(keys), n)
+		}
+		if !sort.SliceIsSorted(keys, func(i, j int) bool { return keys[i] < keys[j] }) {
+			t.Errorf("keys not sorted: %v", keys)
+		}
+	}
+
+	type name string
+	names := SortedKeys(nil, map[name]bool{"b": true, "c": true, "a": false})
+	if len(names) != 3 || names[0] != "a" || names[1] != "b" || names[2] != "c" {
+		t.Errorf("got %v", names)
+	}
+}
+
+func TestSetKeys(t *testing.T) {
+	type flag bool
+	m := map[string]flag{"c": true, "a": true, "b": false}
+	keys := SetKeys(nil, m)
+	if len(keys) != 2 || keys[0] != "a" || keys[1] != "c" {
+		t.Errorf("got %v", keys)
+	}
+	if n := SetLen(m); n != 2 {
+		t.Errorf("SetLen = %d, want 2", n)
+	}
+}
+
+func TestSortedKeysNoAlloc(t *testing.T) {
+	m := map[string]int{"x": 1, "y": 2, "z": 3}
+	allocs := testing.AllocsPerRun(100, func() {
+		var buf [8]string
+		_ = SortedKeys(buf[:0], m)
+	})
+	if allocs != 0 {
+		t.Errorf("SortedKeys allocated %v times for a small map", allocs)
+	}
+}
@@ -0,0 +1,44 @@
+package csmsgp
+
+import "encoding"
+
+// The functions below are the shims used for types that
+// implement encoding.TextMarshaler or encoding.BinaryMarshaler
+// but no msgp methods. Text types are written as str and
+// binary types as bin. They take and return values, so that
+// types with pointer receivers like big.Int fit, too.
+
+// MarshalText returns v.MarshalText() as a string.
+func MarshalText[T any, PT interface {
+	*T
+	encoding.TextMarshaler
+}](v T) (string, error) {
+	b, err := PT(&v).MarshalText()
+	return string(b), err
+}
+
+// UnmarshalText returns the T that s is the text of.
+func UnmarshalText[T any, PT interface {
+	*T
+	encoding.TextUnmarshaler
+}](s string) (v T, err error) {
+	err = PT(&v).UnmarshalText([]byte(s))
+	return
+}
+
+// MarshalBinary returns v.MarshalBinary().
+func MarshalBinary[T any, PT interface {
+	*T
+	encoding.BinaryMarshaler
+}](v T) ([]byte, error) {
+	return PT(&v).MarshalBinary()
+}
+
+// UnmarshalBinary returns the T that b is the binary form of.
+func UnmarshalBinary[T any, PT interface {
+	*T
+	encoding.BinaryUnmarshaler
+}](b []byte) (v T, err error) {
+	err = PT(&v).UnmarshalBinary(b)
+	return
+}
@@ -0,0 +1,47 @@
+package csmsgp
+
+import (
+	"bytes"
+	"math/big"
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestTextShims(t *testing.T) {
+	ip := net.ParseIP("10.0.0.1")
+	s, err := MarshalText(ip)
+	if err != nil || s != "10.0.0.1" {
+		t.Fatalf("MarshalText(net.IP) = %q, %v", s, err)
+	}
+	back, err := UnmarshalText[net.IP](s)
+	if err != nil || !back.Equal(ip) {
+		t.Fatalf("UnmarshalText[net.IP](%q) = %v, %v", s, back, err)
+	}
+
+	// pointer receivers
+	n, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+	s, err = MarshalText(*n)
+	if err != nil || s != n.String() {
+		t.Fatalf("MarshalText(big.Int) = %q, %v", s, err)
+	}
+	m, err := UnmarshalText[big.Int](s)
+	if err != nil || m.Cmp(n) != 0 {
+		t.Fatalf("UnmarshalText[big.Int](%q) = %v, %v", s, &m, err)
+	}
+	if _, err = UnmarshalText[big.Int]("x"); err == nil {
+		t.Error("expected an error for invalid text")
+	}
+}
+
+func TestBinaryShims(t *testing.T) {
+	addr := netip.MustParseAddr("::1")
+	b, err := MarshalBinary(addr)
+	if err != nil || !bytes.Equal(b, addr.AsSlice()) {
+		t.Fatalf("MarshalBinary(netip.Addr) = %x, %v", b, err)
+	}
+	back, err := UnmarshalBinary[netip.Addr](b)
+	if err != nil || back != addr {
+		t.Fatalf("UnmarshalBinary[netip.Addr](%x) = %v, %v", b, back, err)
+	}
+}
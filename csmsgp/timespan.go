@@ -0,0 +1,33 @@
+package csmsgp
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// NanosecondsPerTick is the length of one C# TimeSpan tick.
+const NanosecondsPerTick = 100
+
+// ErrTimeSpanOverflow is returned when a TimeSpan does
+// not fit into a time.Duration. TimeSpan covers about
+// ±29227 years, time.Duration about ±292 years.
+var ErrTimeSpanOverflow = errors.New("csmsgp: TimeSpan out of time.Duration range")
+
+// DurationToTicks converts d to C# TimeSpan ticks.
+// Sub-tick precision is truncated toward zero, as
+// TimeSpan has a resolution of 100ns. It never fails;
+// the error is there to fit the shim convert mode.
+func DurationToTicks(d time.Duration) (int64, error) {
+	return int64(d) / NanosecondsPerTick, nil
+}
+
+// TicksToDuration converts C# TimeSpan ticks to a
+// time.Duration, failing with ErrTimeSpanOverflow
+// if the value is out of range.
+func TicksToDuration(ticks int64) (time.Duration, error) {
+	if ticks > math.MaxInt64/NanosecondsPerTick || ticks < math.MinInt64/NanosecondsPerTick {
+		return 0, ErrTimeSpanOverflow
+	}
+	return time.Duration(ticks * NanosecondsPerTick), nil
+}
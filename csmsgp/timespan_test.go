@@ -0,0 +1,35 @@
+package csmsgp
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTimeSpanTicks(t *testing.T) {
+	for _, c := range []struct {
+		d     time.Duration
+		ticks int64
+	}{
+		{0, 0},
+		{time.Second, 10000000},
+		{-time.Millisecond, -10000},
+		{150 * time.Nanosecond, 1}, // truncated
+		{-150 * time.Nanosecond, -1},
+	} {
+		ticks, _ := DurationToTicks(c.d)
+		if ticks != c.ticks {
+			t.Errorf("DurationToTicks(%v) = %d, want %d", c.d, ticks, c.ticks)
+		}
+	}
+
+	d, err := TicksToDuration(10000000)
+	if err != nil || d != time.Second {
+		t.Errorf("TicksToDuration(10000000) = %v, %v", d, err)
+	}
+	for _, ticks := range []int64{math.MaxInt64, math.MinInt64, math.MaxInt64/100 + 1} {
+		if _, err = TicksToDuration(ticks); err != ErrTimeSpanOverflow {
+			t.Errorf("TicksToDuration(%d) error = %v, want ErrTimeSpanOverflow", ticks, err)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aggronmagi/csmsgp2go/gen"
+	"github.com/aggronmagi/csmsgp2go/generate"
+	"github.com/aggronmagi/csmsgp2go/parse"
+)
+
+const diagnosticsSrc = `package diag
+
+//msgp:unknown
+
+type A struct {
+	B *int     ` + "`msg:\"0\"`" + `
+	C chan int ` + "`msg:\"x\"`" + `
+}
+
+//msgp:shim Celsius as:float64 using:toF/fromF
+
+type B struct {
+	T Celsius ` + "`msg:\"0\"`" + `
+}
+
+type C struct {
+	L B ` + "`msg:\"1,lazy\"`" + `
+}
+`
+
+// All errors in a file are reported, in order, as file:line:col: message.
+func TestDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	tfile := filepath.Join(dir, "diag.go")
+	if err := os.WriteFile(tfile, []byte(diagnosticsSrc), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mode := gen.Marshal | gen.Unmarshal | gen.Size
+
+	for _, tc := range []struct {
+		strict bool
+		want   []string
+	}{
+		{false, []string{
+			tfile + `:3:1: unknown directive "unknown"`,
+			tfile + `:6:4: unsupported pointer type *int`,
+			tfile + `:7:13: invalid index "x": expected 0 to 65535`,
+			tfile + `:17:2: lazy field L must be declared as msgp.Raw, with the decoded type in the tag: L msgp.Raw ` + "`msg:\"1,lazy:B\"`",
+		}},
+		{true, []string{
+			tfile + `:3:1: unknown directive "unknown"`,
+			tfile + `:6:4: unsupported pointer type *int`,
+			tfile + `:7:13: invalid index "x": expected 0 to 65535`,
+			tfile + `:13:4: non-local identifier Celsius`,
+			tfile + `:17:2: lazy field L must be declared as msgp.Raw, with the decoded type in the tag: L msgp.Raw ` + "`msg:\"1,lazy:B\"`",
+		}},
+	} {
+		err := Run(generate.Options{File: tfile, Mode: mode, Strict: tc.strict})
+		if err == nil {
+			t.Fatalf("strict=%v: no error", tc.strict)
+		}
+		if got, want := err.Error(), strings.Join(tc.want, "\n"); got != want {
+			t.Errorf("strict=%v: got\n%s\nwant\n%s", tc.strict, got, want)
+		}
+	}
+}
+
+// Types from the other files of the package are warnings,
+// unless their definition is used.
+func TestSiblingDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	tfile := filepath.Join(dir, "a.go")
+	files := map[string]string{
+		"a.go": "package sib\n\ntype Inner Outer\n\ntype Same = Inner\n\ntype A struct {\n\tO Outer `msg:\"0\"`\n\tS Same  `msg:\"1\"`\n}\n",
+		"b.go": "package sib\n\ntype Outer struct {\n\tX int32 `msg:\"0\"`\n}\n",
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mode := gen.Marshal | gen.Unmarshal | gen.Size
+	if err := Run(generate.Options{File: tfile, Mode: mode}); err != nil {
+		t.Fatal(err)
+	}
+	err := Run(generate.Options{File: tfile, Mode: mode, Strict: true})
+	want := tfile + ":8:4: identifier Outer is declared in b.go, outside the parsed files"
+	if err == nil || err.Error() != want {
+		t.Errorf("strict: got %v; want %s", err, want)
+	}
+}
+
+const lintSrc = `package lint
+
+import "time"
+
+type Count uint
+
+//msgp:timespan
+
+type A struct {
+	N Count         ` + "`msg:\"0\"`" + `
+	C []complex128  ` + "`msg:\"1\"`" + `
+	T time.Time     ` + "`msg:\"2\"`" + `
+	D time.Duration ` + "`msg:\"3\"`" + `
+	M map[int32]any ` + "`msg:\"4\"`" + `
+}
+`
+
+func TestLintCsharp(t *testing.T) {
+	dir := t.TempDir()
+	tfile := filepath.Join(dir, "lint.go")
+	if err := os.WriteFile(tfile, []byte(lintSrc), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fs, err := parse.File(tfile, parse.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		tfile + ":5:12: type Count: uint has a platform-dependent size; use uint64 (C# ulong) or uint32 (C# uint)",
+		tfile + ":11:6: field A.C: complex128 is written as a msgp extension that C# can't read; use two float64 fields (C# double)",
+		tfile + ":12:4: field A.T: time.Time is written as msgp extension 5; use //msgp:newtime (C# DateTime)",
+		tfile + ":14:14: field A.M: interface{} only round-trips with the C# Typeless resolver; use a concrete type (C# object needs TypelessFormatter)",
+	}
+	err = fs.LintCsharp()
+	if err == nil {
+		t.Fatal("no lint errors")
+	}
+	if got, want := err.Error(), strings.Join(want, "\n"); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -3,7 +3,6 @@ package gen
 import (
 	"io"
 	"strconv"
-	"strings"
 )
 
 func decode(w io.Writer) *decodeGen {
@@ -47,10 +46,10 @@ func (d *decodeGen) Execute(p Elem, ctx Context) error {
 
 	d.p.comment("DecodeMsg implements msgp.Decodable")
 
-	d.p.printf("\nfunc (%s %s) DecodeMsg(dc *msgp.Reader) (err error) {", p.Varname(), methodReceiver(p))
+	d.p.printf("\nfunc (%s %s) DecodeMsg(dc *msgp.Reader) (err error) {", p.Varname(), methodReceiver(d.ctx, p))
 	next(d, p)
 	d.p.nakedReturn()
-	unsetReceiver(p)
+	unsetReceiver(d.ctx, p)
 	return d.p.err
 }
 
@@ -73,10 +72,33 @@ func (d *decodeGen) assignAndCheck(name string, typ string) {
 	d.p.wrapErrCheck(d.ctx.ArgsStr())
 }
 
+// readHeader reads an array or map header into sz.
+// C# writes null collections as nil, which is read as
+// an empty collection.
+func (d *decodeGen) readHeader(sz string, typ string) {
+	d.p.declare(sz, u32)
+	d.p.print("\nif dc.IsNil() {\nerr = dc.ReadNil()")
+	d.p.wrapErrCheck(d.ctx.ArgsStr())
+	d.p.print("\n} else {")
+	d.assignAndCheck(sz, typ)
+	d.p.closeblock()
+	d.p.maxLenCheck(sz, d.ctx)
+}
+
+// openNil opens a block that reads a nil in place of e
+// and leaves e zeroed. The caller closes the block.
+func (d *decodeGen) openNil(e Elem) {
+	d.p.print("\nif dc.IsNil() {\nerr = dc.ReadNil()")
+	d.p.wrapErrCheck(d.ctx.ArgsStr())
+	d.p.zeroValue(e)
+	d.p.print("\n} else {")
+}
+
 func (d *decodeGen) structAsTuple(s *Struct) {
-	nfields := len(s.Fields)
+	nfields := s.ArrayLen()
 
-	sz := randIdent()
+	d.openNil(s)
+	sz := d.ctx.randIdent()
 	d.p.declare(sz, u32)
 	d.assignAndCheck(sz, arrayHeader)
 	d.p.arrayCheck(strconv.Itoa(nfields), sz)
@@ -100,6 +122,7 @@ func (d *decodeGen) structAsTuple(s *Struct) {
 			d.p.printf("\n}") // close if statement
 		}
 	}
+	d.p.closeblock() // close nil check
 }
 
 // func (d *decodeGen) structAsMap(s *Struct) {
@@ -189,7 +212,7 @@ func (d *decodeGen) gBase(b *BaseElem) {
 	// open block for 'tmp'
 	var tmp string
 	if b.Convert && b.Value != IDENT { // we don't need block for 'tmp' in case of IDENT
-		tmp = randIdent()
+		tmp = d.ctx.randIdent()
 		d.p.printf("\n{ var %s %s", tmp, b.BaseType())
 	}
 
@@ -202,13 +225,19 @@ func (d *decodeGen) gBase(b *BaseElem) {
 	switch b.Value {
 	case Bytes:
 		if b.Convert {
+			checkNil = tmp
+		}
+		d.p.printf("\nif dc.IsNil() {\nerr = dc.ReadNil()\n%[1]s = %[1]s[:0]\n} else {", checkNil)
+		if b.Convert && b.ShimMode == Convert {
+			// the conversion may fail, so there is no buffer to reuse
+			d.p.printf("\n%s, err = dc.ReadBytes(nil)", tmp)
+		} else if b.Convert {
 			lowered := b.ToBase() + "(" + vname + ")"
 			d.p.printf("\n%s, err = dc.ReadBytes(%s)", tmp, lowered)
-			checkNil = tmp
 		} else {
 			d.p.printf("\n%s, err = dc.ReadBytes(%s)", vname, vname)
-			checkNil = vname
 		}
+		d.p.closeblock()
 	case IDENT:
 		if b.Convert {
 			lowered := b.ToBase() + "(" + vname + ")"
@@ -218,6 +247,11 @@ func (d *decodeGen) gBase(b *BaseElem) {
 		}
 	case Ext:
 		d.p.printf("\nerr = dc.ReadExtension(%s)", vname)
+	case BigInt, BigIntPtr:
+		if b.Value == BigIntPtr {
+			d.p.printf("\nif %[1]s == nil {\n%[1]s = new(big.Int)\n}", vname)
+		}
+		d.p.printf("\nerr = csmsgp.ReadBigInt(dc, %s)", vname)
 	default:
 		if b.Convert {
 			d.p.printf("\n%s, err = dc.Read%s()", tmp, bname)
@@ -247,20 +281,23 @@ func (d *decodeGen) gMap(m *Map) {
 	if !d.p.ok() {
 		return
 	}
-	sz := randIdent()
+	if m.IsSet {
+		d.gSet(m)
+		return
+	}
+	sz := d.ctx.randIdent()
 
 	// resize or allocate map
-	d.p.declare(sz, u32)
-	d.assignAndCheck(sz, mapHeader)
+	d.readHeader(sz, mapHeader)
 	d.p.resizeMap(sz, m)
 
 	// for element in map, read string/value
 	// pair and assign
 	d.needsField()
 	d.p.printf("\nfor %s > 0 {\n%s--", sz, sz)
-	d.p.declare(m.Keyidx, m.Key.TypeName())
+	d.p.declare(m.Keyidx, m.KeyBaseType())
 	d.p.declare(m.Validx, m.Value.TypeName())
-	d.assignAndCheck(m.Keyidx, strings.Title(m.Key.TypeName())) // stringTyp)
+	d.assignAndCheck(m.Keyidx, m.KeyBaseName())
 	d.ctx.PushVar(m.Keyidx)
 	m.Value.SetIsAllowNil(false)
 	next(d, m.Value)
@@ -269,13 +306,24 @@ func (d *decodeGen) gMap(m *Map) {
 	d.p.closeblock()
 }
 
+// gSet reads an array of keys into a set.
+func (d *decodeGen) gSet(m *Map) {
+	sz := d.ctx.randIdent()
+	d.readHeader(sz, arrayHeader)
+	d.p.resizeMap(sz, m)
+	d.p.printf("\nfor %s > 0 {\n%s--", sz, sz)
+	d.p.declare(m.Keyidx, m.KeyBaseType())
+	d.assignAndCheck(m.Keyidx, m.KeyBaseName())
+	d.p.setAssign(m)
+	d.p.closeblock()
+}
+
 func (d *decodeGen) gSlice(s *Slice) {
 	if !d.p.ok() {
 		return
 	}
-	sz := randIdent()
-	d.p.declare(sz, u32)
-	d.assignAndCheck(sz, arrayHeader)
+	sz := d.ctx.randIdent()
+	d.readHeader(sz, arrayHeader)
 	if s.isAllowNil {
 		d.p.resizeSliceNoNil(sz, s)
 	} else {
@@ -289,17 +337,19 @@ func (d *decodeGen) gArray(a *Array) {
 		return
 	}
 
+	d.openNil(a)
 	// special case if we have [const]byte
 	if be, ok := a.Els.(*BaseElem); ok && (be.Value == Byte || be.Value == Uint8) {
 		d.p.printf("\nerr = dc.ReadExactBytes((%s)[:])", a.Varname())
 		d.p.wrapErrCheck(d.ctx.ArgsStr())
-		return
+	} else {
+		sz := d.ctx.randIdent()
+		d.p.declare(sz, u32)
+		d.assignAndCheck(sz, arrayHeader)
+		d.p.arrayCheck(coerceArraySize(a.Size), sz)
+		d.p.rangeBlock(d.ctx, a.Index, a.Varname(), d, a.Els)
 	}
-	sz := randIdent()
-	d.p.declare(sz, u32)
-	d.assignAndCheck(sz, arrayHeader)
-	d.p.arrayCheck(coerceArraySize(a.Size), sz)
-	d.p.rangeBlock(d.ctx, a.Index, a.Varname(), d, a.Els)
+	d.p.closeblock() // close nil check
 }
 
 func (d *decodeGen) gPtr(p *Ptr) {
@@ -315,16 +365,30 @@ func (d *decodeGen) gPtr(p *Ptr) {
 	//d.p.closeblock()
 }
 
-func (d *decodeGen) gNilSpaceholder() {
+func (d *decodeGen) gNilSpaceholder(n *NilPlaceholder) {
 	if !d.p.ok() {
 		return
 	}
-	d.p.printf("\nerr = dc.ReadNil(); if err != nil { return; }")
+	// unused indexes are skipped, whatever they hold
+	if n.Len() == 1 {
+		d.p.print("\nerr = dc.Skip()")
+	} else {
+		d.p.printf("\nfor %[1]s := 0; %[1]s < %[2]d && err == nil; %[1]s++ {\nerr = dc.Skip()\n}", d.ctx.randIdent(), n.Len())
+	}
+	d.p.print("\nif err != nil {\nreturn\n}") // unused indexes have no name
 }
 
 func (d *decodeGen) gCsharpString(s *CsharpString) {
 	if !d.p.ok() {
 		return
 	}
-	d.p.printf("\n"+`if dc.IsNil() {err = dc.ReadNil(); return}else {%s, err = dc.ReadString(); if err != nil {return}}`, s.Varname())
+	d.p.printf("\nif dc.IsNil() {\nerr = dc.ReadNil()\n%s = \"\"\n} else {", s.Varname())
+	if s.Named() {
+		tmp := d.ctx.randIdent()
+		d.p.printf("\nvar %[1]s string\n%[1]s, err = dc.ReadString()\n%[2]s = %[3]s(%[1]s)", tmp, s.Varname(), s.TypeName())
+	} else {
+		d.p.printf("\n%s, err = dc.ReadString()", s.Varname())
+	}
+	d.p.closeblock()
+	d.p.wrapErrCheck(d.ctx.ArgsStr())
 }
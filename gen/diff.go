@@ -0,0 +1,217 @@
+package gen
+
+import (
+	"io"
+	"strconv"
+)
+
+func diff(w io.Writer) *diffGen {
+	return &diffGen{
+		p: printer{w: w},
+	}
+}
+
+// diffGen prints Diff and ApplyPatch for struct types.
+//
+// A patch is a map of field index to value. Only fields
+// that differ are written. Nested structs are written as
+// nested patches instead of whole values, so a change deep
+// inside a large object stays small on the wire.
+type diffGen struct {
+	passes
+	p   printer
+	ctx *Context
+	m   *marshalGen
+	u   *unmarshalGen
+}
+
+func (d *diffGen) Method() Method { return Diff }
+
+func (d *diffGen) Apply(dirs []string) error {
+	return nil
+}
+
+func (d *diffGen) Execute(p Elem, ctx Context) error {
+	d.ctx = &ctx
+	if !d.p.ok() {
+		return d.p.err
+	}
+	p = d.applyall(p)
+	if p == nil {
+		return nil
+	}
+	if !IsPrintable(p) {
+		return nil
+	}
+	st, ok := p.(*Struct)
+	if !ok {
+		return nil
+	}
+	d.m = marshal(d.p.w)
+	d.m.ctx = d.ctx
+	d.u = unmarshal(d.p.w)
+	d.u.ctx = d.ctx
+
+	name := st.TypeName()
+	vn := st.Varname()
+
+	d.p.comment("Diff returns a patch that turns old into z.")
+	d.p.print("\n// Only changed fields are written, keyed by field index;")
+	d.p.print("\n// nested structs are diffed recursively.")
+	d.p.printf("\nfunc (%s *%s) Diff(old *%s) (o []byte, err error) {", vn, name, name)
+	d.p.printf("\no, _, err = %s.diff(old, nil)", vn)
+	d.p.nakedReturn()
+
+	d.p.printf("\nfunc (%s *%s) diff(old *%s, b []byte) (o []byte, n uint16, err error) {", vn, name, name)
+	d.p.print("\no = b")
+	d.diffStruct(st, vn, "old", "n")
+	d.p.nakedReturn()
+
+	d.p.comment("ApplyPatch applies a patch produced by Diff to z.")
+	d.p.printf("\nfunc (%s *%s) ApplyPatch(bts []byte) (err error) {", vn, name)
+	d.p.printf("\n_, err = %s.applyPatch(bts)", vn)
+	d.p.nakedReturn()
+
+	d.p.printf("\nfunc (%s *%s) applyPatch(bts []byte) (o []byte, err error) {", vn, name)
+	d.patchStruct(st, vn)
+	d.p.print("\no = bts")
+	d.p.nakedReturn()
+
+	return d.err()
+}
+
+func (d *diffGen) err() error {
+	for _, p := range []*printer{&d.p, &d.m.p, &d.u.p} {
+		if p.err != nil {
+			return p.err
+		}
+	}
+	return nil
+}
+
+// isStructIdent reports whether e refers to a struct type
+// that gets its own diff/applyPatch methods.
+func (d *diffGen) isStructIdent(e Elem) bool {
+	be, ok := e.(*BaseElem)
+	return ok && be.Value == IDENT && !be.Convert && d.ctx.structs[be.TypeName()]
+}
+
+// diffStruct appends the patch of cur against old,
+// counting the written fields in cnt.
+func (d *diffGen) diffStruct(s *Struct, cur, old, cnt string) {
+	hdr := d.ctx.randIdent()
+	d.p.printf("\n%s := len(o)", hdr)
+	d.p.print("\no = msgp.AppendMapHeader(o, 0xffff) // entry count is patched below")
+	for i := range s.Fields {
+		sf := &s.Fields[i]
+		if _, ok := sf.FieldElem.(*NilPlaceholder); ok {
+			continue
+		}
+		if !d.p.ok() {
+			return
+		}
+		curX := cur + "." + sf.FieldName
+		oldX := old + "." + sf.FieldName
+		d.ctx.PushString(sf.FieldName)
+		switch {
+		case isStruct(sf.FieldElem):
+			mark, sub := d.ctx.randIdent(), d.ctx.randIdent()
+			d.p.printf("\n// idx %d", sf.FieldTag)
+			d.p.printf("\n%s := len(o)", mark)
+			d.p.printf("\no = msgp.AppendUint16(o, %d)", sf.FieldTag)
+			d.p.printf("\nvar %s uint16", sub)
+			d.diffStruct(sf.FieldElem.(*Struct), curX, oldX, sub)
+			d.p.printf("\nif %[1]s == 0 { o = o[:%[2]s] } else { %[3]s++ }", sub, mark, cnt)
+		case d.isStructIdent(sf.FieldElem):
+			mark, sub := d.ctx.randIdent(), d.ctx.randIdent()
+			d.p.printf("\n// idx %d", sf.FieldTag)
+			d.p.printf("\n%s := len(o)", mark)
+			d.p.printf("\no = msgp.AppendUint16(o, %d)", sf.FieldTag)
+			d.p.printf("\nvar %s uint16", sub)
+			d.p.printf("\no, %s, err = %s.diff(&%s, o)", sub, curX, oldX)
+			d.p.wrapErrCheck(d.ctx.ArgsStr())
+			d.p.printf("\nif %[1]s == 0 { o = o[:%[2]s] } else { %[3]s++ }", sub, mark, cnt)
+		default:
+			d.p.printf("\nif %s { // idx %d", changedExpr(sf.FieldElem, curX, oldX), sf.FieldTag)
+			d.p.printf("\no = msgp.AppendUint16(o, %d)", sf.FieldTag)
+			sf.FieldElem.SetIsAllowNil(false)
+			next(d.m, sf.FieldElem)
+			d.m.fuseHook()
+			d.p.printf("\n%s++", cnt)
+			d.p.closeblock()
+		}
+		d.ctx.Pop()
+	}
+	d.p.printf("\no[%[1]s+1], o[%[1]s+2] = byte(%[2]s>>8), byte(%[2]s)", hdr, cnt)
+}
+
+// patchStruct reads a patch into cur.
+func (d *diffGen) patchStruct(s *Struct, cur string) {
+	sz, idx := d.ctx.randIdent(), d.ctx.randIdent()
+	d.p.declare(sz, u32)
+	d.u.assignAndCheck(sz, mapHeader)
+	d.p.printf("\nfor %[1]s > 0 {\n%[1]s--", sz)
+	d.p.declare(idx, "uint16")
+	d.u.assignAndCheck(idx, "Uint16")
+	d.p.printf("\nswitch %s {", idx)
+	for i := range s.Fields {
+		sf := &s.Fields[i]
+		if _, ok := sf.FieldElem.(*NilPlaceholder); ok {
+			continue
+		}
+		if !d.p.ok() {
+			return
+		}
+		d.p.printf("\ncase %s:", strconv.Itoa(int(sf.FieldTag)))
+		d.ctx.PushString(sf.FieldName)
+		switch {
+		case isStruct(sf.FieldElem):
+			d.patchStruct(sf.FieldElem.(*Struct), cur+"."+sf.FieldName)
+		case d.isStructIdent(sf.FieldElem):
+			d.p.printf("\nbts, err = %s.%s.applyPatch(bts)", cur, sf.FieldName)
+			d.p.wrapErrCheck(d.ctx.ArgsStr())
+		default:
+			sf.FieldElem.SetIsAllowNil(false)
+			next(d.u, sf.FieldElem)
+		}
+		d.ctx.Pop()
+	}
+	d.p.print("\ndefault:\nbts, err = msgp.Skip(bts)")
+	d.p.wrapErrCheck(d.ctx.ArgsStr())
+	d.p.closeblock() // close switch
+	d.p.closeblock() // close for loop
+}
+
+func isStruct(e Elem) bool {
+	_, ok := e.(*Struct)
+	return ok
+}
+
+// changedExpr returns the expression that is true
+// when the values a and b of element e differ.
+func changedExpr(e Elem, a, b string) string {
+	switch e := e.(type) {
+	case *CsharpString:
+		return a + " != " + b
+	case *BaseElem:
+		if e.ShimToBase != "" {
+			break
+		}
+		switch e.Value {
+		case Bytes:
+			return "!bytes.Equal(" + a + ", " + b + ")"
+		case Time:
+			if !e.Convert {
+				return "!" + a + ".Equal(" + b + ")"
+			}
+		case IDENT:
+			if e.unity {
+				return a + " != " + b
+			}
+		case Intf, Ext, BigInt, BigIntPtr:
+		default:
+			return a + " != " + b
+		}
+	}
+	return "!reflect.DeepEqual(" + a + ", " + b + ")"
+}
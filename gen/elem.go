@@ -5,20 +5,23 @@ import (
 	"strings"
 )
 
-var (
-	identNext   = 0
-	identPrefix = "za"
-)
+// identGen generates the identifiers in generated code.
+// Each Printer has its own, so that printers can run
+// concurrently with the same output.
+type identGen struct {
+	prefix string
+	next   int
+}
 
-func resetIdent(prefix string) {
-	identPrefix = prefix
-	identNext = 0
+func (g *identGen) reset(prefix string) {
+	g.prefix = prefix
+	g.next = 0
 }
 
 // generate a random identifier name
-func randIdent() string {
-	identNext++
-	return fmt.Sprintf("%s%04d", identPrefix, identNext)
+func (g *identGen) randIdent() string {
+	g.next++
+	return fmt.Sprintf("%s%04d", g.prefix, g.next)
 }
 
 // This code defines the type declaration tree.
@@ -93,6 +96,8 @@ const (
 	Duration   // time.Duration
 	Ext        // extension
 	JsonNumber // json.Number
+	BigInt     // big.Int, as C# BigInteger
+	BigIntPtr  // *big.Int, as C# BigInteger
 
 	IDENT // IDENT means an unrecognized identifier
 )
@@ -125,6 +130,8 @@ var primitives = map[string]Primitive{
 	"time.Duration":  Duration,
 	"msgp.Extension": Ext,
 	"json.Number":    JsonNumber,
+	"big.Int":        BigInt,
+	"*big.Int":       BigIntPtr,
 }
 
 // types built into the library
@@ -135,18 +142,60 @@ var builtins = map[string]struct{}{
 	"msgp.Number": {},
 }
 
+// providedTypes are the types of the csmsgp runtime package.
+// Like the unity types, they are builtins with an ExactMsgsize method.
+var providedTypes = map[string]struct{}{
+	"csmsgp.DateTimeOffset": {},
+}
+
+// UnityPath is the import path of the unity package of this module.
+const UnityPath = "github.com/aggronmagi/csmsgp2go/unity"
+
+// unityTypes are the types of the unity package. They are
+// builtins with a fixed encoded size, found by import path
+// rather than by name; see UnityIdent.
+var unityTypes = map[string]struct{}{
+	"Vector2":    {},
+	"Vector3":    {},
+	"Vector4":    {},
+	"Quaternion": {},
+	"Color":      {},
+	"Rect":       {},
+	"Bounds":     {},
+}
+
+// IsUnityType reports whether name is a type of the unity package.
+func IsUnityType(name string) bool {
+	_, ok := unityTypes[name]
+	return ok
+}
+
+func init() {
+	for name := range providedTypes {
+		builtins[name] = struct{}{}
+	}
+}
+
+// hasExactMsgsize reports whether the builtin b
+// has an ExactMsgsize method.
+func hasExactMsgsize(b *BaseElem) bool {
+	_, provided := providedTypes[b.TypeName()]
+	return provided || b.unity
+}
+
 // common data/methods for every Elem
 type common struct {
 	vname, alias string
 	ptrRcv       bool
 }
 
-func (c *common) SetVarname(s string) { c.vname = s }
-func (c *common) Varname() string     { return c.vname }
-func (c *common) Alias(typ string)    { c.alias = typ }
-func (c *common) hidden()             {}
-func (c *common) AllowNil() bool      { return false }
-func (c *common) SetIsAllowNil(bool)  {}
+func (c *common) setVarname(s string, _ *identGen) { c.vname = s }
+func (c *common) SetVarname(s string)              { c.vname = s }
+func (c *common) Varname() string                  { return c.vname }
+func (c *common) Alias(typ string)                 { c.alias = typ }
+func (c *common) hidden()                          {}
+func (c *common) AllowNil() bool                   { return false }
+func (c *common) SetIsAllowNil(bool)               {}
 func (c *common) AlwaysPtr(set *bool) bool {
 	if c != nil && set != nil {
 		c.ptrRcv = *set
@@ -166,11 +215,17 @@ func IsPrintable(e Elem) bool {
 // implemented by *Ptr, *Struct, *Array,
 // *Slice, *Map, and *BaseElem.
 type Elem interface {
-	// SetVarname sets this nodes
+	// setVarname sets this nodes
 	// variable name and recursively
-	// sets the names of all its children.
+	// sets the names of all its children,
+	// using g for index variables.
 	// In general, this should only be
 	// called on the parent of the tree.
+	setVarname(s string, g *identGen)
+
+	// SetVarname is setVarname for callers outside
+	// the package, with index variables named as
+	// by Printer.Print.
 	SetVarname(s string)
 
 	// Varname returns the variable
@@ -233,6 +288,15 @@ func Ident(id string) *BaseElem {
 	return be
 }
 
+// UnityIdent returns the *BaseElem for id, a type of the unity
+// package as it is written in the file, e.g. "u.Vector3" when
+// the package is imported as u.
+func UnityIdent(id string) *BaseElem {
+	be := Ident(id)
+	be.unity = true
+	return be
+}
+
 type Array struct {
 	common
 	Index string // index variable name
@@ -240,10 +304,12 @@ type Array struct {
 	Els   Elem   // child
 }
 
-func (a *Array) SetVarname(s string) {
-	a.common.SetVarname(s)
+func (a *Array) SetVarname(s string) { a.setVarname(s, &identGen{prefix: "za"}) }
+
+func (a *Array) setVarname(s string, g *identGen) {
+	a.common.setVarname(s, g)
 ridx:
-	a.Index = randIdent()
+	a.Index = g.randIdent()
 
 	// try to avoid using the same
 	// index as a parent slice
@@ -251,7 +317,7 @@ ridx:
 		goto ridx
 	}
 
-	a.Els.SetVarname(fmt.Sprintf("%s[%s]", a.Varname(), a.Index))
+	a.Els.setVarname(fmt.Sprintf("%s[%s]", a.Varname(), a.Index), g)
 }
 
 func (a *Array) TypeName() string {
@@ -279,14 +345,25 @@ func (a *Array) ZeroExpr() string { return "" }
 // IfZeroExpr unsupported
 func (a *Array) IfZeroExpr() string { return "" }
 
+// NilPlaceholder stands for a run of unused field
+// indexes in a struct, which are written as nils.
 type NilPlaceholder struct {
 	common
+	Count int // number of consecutive indexes
 }
 
 var _ Elem = &NilPlaceholder{}
 
 func (n *NilPlaceholder) Copy() Elem {
-	return &NilPlaceholder{}
+	return &NilPlaceholder{Count: n.Count}
+}
+
+// Len returns the number of nils, at least one.
+func (n *NilPlaceholder) Len() int {
+	if n.Count < 1 {
+		return 1
+	}
+	return n.Count
 }
 
 func (n *NilPlaceholder) TypeName() string   { return "" }
@@ -306,6 +383,10 @@ func (a *CsharpString) TypeName() string {
 	return a.common.alias
 }
 
+// Named reports whether the string has a named type,
+// e.g. `type ItemName string`, and needs a conversion.
+func (a *CsharpString) Named() bool { return a.TypeName() != "string" }
+
 func (a *CsharpString) Copy() Elem {
 	b := *a
 	return &b
@@ -325,37 +406,95 @@ type Map struct {
 	Validx     string // value variable name
 	Value      Elem   // value element
 	Key        Elem   // key element
+	IsSet      bool   // written as an array of keys (C# HashSet<T>)
+	Sorted     bool   // written in key order
 	isAllowNil bool
 }
 
-func (m *Map) SetVarname(s string) {
-	m.common.SetVarname(s)
+func (m *Map) SetVarname(s string) { m.setVarname(s, &identGen{prefix: "za"}) }
+
+func (m *Map) setVarname(s string, g *identGen) {
+	m.common.setVarname(s, g)
 ridx:
-	m.Keyidx = randIdent()
-	m.Validx = randIdent()
+	m.Keyidx = g.randIdent()
+	m.Validx = g.randIdent()
 
 	// just in case
 	if m.Keyidx == m.Validx {
 		goto ridx
 	}
 
-	m.Value.SetVarname(m.Validx)
+	m.Value.setVarname(m.Validx, g)
 }
 
 func (m *Map) TypeName() string {
 	if m.common.alias != "" {
 		return m.common.alias
 	}
-	m.common.Alias("map[string]" + m.Value.TypeName())
+	m.common.Alias("map[" + m.Key.TypeName() + "]" + m.Value.TypeName())
 	return m.common.alias
 }
 
 func (m *Map) Copy() Elem {
 	g := *m
+	g.Key = m.Key.Copy()
 	g.Value = m.Value.Copy()
 	return &g
 }
 
+// SetElemExpr returns the value stored for each key
+// of a set: struct{}{} or true.
+func (m *Map) SetElemExpr() string {
+	if m.BoolSet() {
+		return "true"
+	}
+	return m.Value.TypeName() + "{}"
+}
+
+// BoolSet reports whether m is a set kept as a map[K]bool,
+// whose keys with a false value are not in the set.
+func (m *Map) BoolSet() bool {
+	vb, ok := m.Value.(*BaseElem)
+	return ok && vb.Value == Bool
+}
+
+// KeyBaseName returns the name of the primitive the key
+// is read and written as, e.g. "Int64" for map[UserID]T
+// with `type UserID int64`.
+func (m *Map) KeyBaseName() string {
+	if kb, ok := m.Key.(*BaseElem); ok {
+		return kb.BaseName()
+	}
+	return strings.Title(m.Key.TypeName())
+}
+
+// KeyBaseType returns the Go type of the primitive
+// the key is read and written as, e.g. "int64".
+func (m *Map) KeyBaseType() string {
+	if kb, ok := m.Key.(*BaseElem); ok {
+		return kb.BaseType()
+	}
+	return m.Key.TypeName()
+}
+
+// KeyToBase returns the expression converting the key
+// variable vn to its primitive type.
+func (m *Map) KeyToBase(vn string) string {
+	if kb, ok := m.Key.(*BaseElem); ok && kb.Convert {
+		return kb.ToBase() + "(" + vn + ")"
+	}
+	return vn
+}
+
+// KeyFromBase returns the expression converting the
+// primitive variable vn back to the key type.
+func (m *Map) KeyFromBase(vn string) string {
+	if kb, ok := m.Key.(*BaseElem); ok && kb.Convert {
+		return kb.FromBase() + "(" + vn + ")"
+	}
+	return vn
+}
+
 func (m *Map) Complexity() int {
 	// Complexity of maps are considered constant. Children should decide on their own.
 	return 3
@@ -380,15 +519,17 @@ type Slice struct {
 	Els        Elem // The type of each element
 }
 
-func (s *Slice) SetVarname(a string) {
-	s.common.SetVarname(a)
-	s.Index = randIdent()
+func (s *Slice) SetVarname(a string) { s.setVarname(a, &identGen{prefix: "za"}) }
+
+func (s *Slice) setVarname(a string, g *identGen) {
+	s.common.setVarname(a, g)
+	s.Index = g.randIdent()
 	varName := s.Varname()
 	if varName[0] == '*' {
 		// Pointer-to-slice requires parenthesis for slicing.
 		varName = "(" + varName + ")"
 	}
-	s.Els.SetVarname(fmt.Sprintf("%s[%s]", varName, s.Index))
+	s.Els.setVarname(fmt.Sprintf("%s[%s]", varName, s.Index), g)
 }
 
 func (s *Slice) TypeName() string {
@@ -437,15 +578,17 @@ type Ptr struct {
 	Value Elem
 }
 
-func (s *Ptr) SetVarname(a string) {
-	s.common.SetVarname(a)
+func (s *Ptr) SetVarname(a string) { s.setVarname(a, &identGen{prefix: "za"}) }
+
+func (s *Ptr) setVarname(a string, g *identGen) {
+	s.common.setVarname(a, g)
 
 	// struct fields are dereferenced
 	// automatically...
 	switch x := s.Value.(type) {
 	case *Struct:
 		// struct fields are automatically dereferenced
-		x.SetVarname(a)
+		x.setVarname(a, g)
 		return
 
 	case *BaseElem:
@@ -456,14 +599,14 @@ func (s *Ptr) SetVarname(a string) {
 			if x.Convert {
 				x.Needsref(false)
 			}
-			x.SetVarname(a)
+			x.setVarname(a, g)
 		} else {
-			x.SetVarname("*" + a)
+			x.setVarname("*"+a, g)
 		}
 		return
 
 	default:
-		s.Value.SetVarname("*" + a)
+		s.Value.setVarname("*"+a, g)
 		return
 	}
 }
@@ -510,6 +653,9 @@ func (s *Struct) TypeName() string {
 	}
 	str := "struct{\n"
 	for i := range s.Fields {
+		if _, ok := s.Fields[i].FieldElem.(*NilPlaceholder); ok {
+			continue
+		}
 		str += s.Fields[i].FieldName +
 			" " + s.Fields[i].FieldElem.TypeName() +
 			" " + s.Fields[i].RawTag + ";\n"
@@ -519,9 +665,11 @@ func (s *Struct) TypeName() string {
 	return s.common.alias
 }
 
-func (s *Struct) SetVarname(a string) {
-	s.common.SetVarname(a)
-	writeStructFields(s.Fields, a)
+func (s *Struct) SetVarname(a string) { s.setVarname(a, &identGen{prefix: "za"}) }
+
+func (s *Struct) setVarname(a string, g *identGen) {
+	s.common.setVarname(a, g)
+	writeStructFields(s.Fields, a, g)
 }
 
 func (s *Struct) Copy() Elem {
@@ -534,6 +682,21 @@ func (s *Struct) Copy() Elem {
 	return &g
 }
 
+// ArrayLen returns the number of array elements
+// the struct is written as, counting every index
+// of a NilPlaceholder run.
+func (s *Struct) ArrayLen() int {
+	n := 0
+	for i := range s.Fields {
+		if np, ok := s.Fields[i].FieldElem.(*NilPlaceholder); ok {
+			n += np.Len()
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
 func (s *Struct) Complexity() int {
 	c := 1
 	for i := range s.Fields {
@@ -600,6 +763,20 @@ func (sf *StructField) HasTagPart(pname string) bool {
 	return false
 }
 
+// TagPartValue returns the value of a "name:value" tag part,
+// e.g. "Inner" for `msg:"5,lazy:Inner"`.
+func (sf *StructField) TagPartValue(pname string) (string, bool) {
+	if len(sf.FieldTagParts) < 2 {
+		return "", false
+	}
+	for _, p := range sf.FieldTagParts[1:] {
+		if v, ok := strings.CutPrefix(p, pname+":"); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
 type ShimMode int
 
 const (
@@ -620,6 +797,7 @@ type BaseElem struct {
 	mustinline   bool      // must inline; not printable
 	needsref     bool      // needs reference for shim
 	allowNil     *bool     // Override from parent.
+	unity        bool      // a type of the unity package
 }
 
 func (s *BaseElem) Printable() bool { return !s.mustinline }
@@ -646,20 +824,22 @@ func (s *BaseElem) SetIsAllowNil(b bool) {
 	s.allowNil = &b
 }
 
-func (s *BaseElem) SetVarname(a string) {
-	// extensions whose parents
-	// are not pointers need to
+func (s *BaseElem) SetVarname(a string) { s.setVarname(a, &identGen{prefix: "za"}) }
+
+func (s *BaseElem) setVarname(a string, g *identGen) {
+	// extensions and big.Ints whose
+	// parents are not pointers need to
 	// be explicitly referenced
-	if s.Value == Ext || s.needsref {
+	if s.Value == Ext || s.Value == BigInt || s.needsref {
 		if strings.HasPrefix(a, "*") {
-			s.common.SetVarname(a[1:])
+			s.common.setVarname(a[1:], g)
 			return
 		}
-		s.common.SetVarname("&" + a)
+		s.common.setVarname("&"+a, g)
 		return
 	}
 
-	s.common.SetVarname(a)
+	s.common.setVarname(a, g)
 }
 
 // TypeName returns the syntactically correct Go
@@ -724,6 +904,10 @@ func (s *BaseElem) BaseType() string {
 		return "json.Number"
 	case Ext:
 		return "msgp.Extension"
+	case BigInt:
+		return "big.Int"
+	case BigIntPtr:
+		return "*big.Int"
 
 	// everything else is base.String() with
 	// the first letter as lowercase
@@ -758,7 +942,7 @@ func (s *BaseElem) Complexity() int {
 func (s *BaseElem) Resolved() bool {
 	if s.Value == IDENT {
 		_, ok := builtins[s.TypeName()]
-		return ok
+		return ok || s.unity
 	}
 	return true
 }
@@ -857,6 +1041,8 @@ func (k Primitive) String() string {
 		return "Extension"
 	case JsonNumber:
 		return "json.Number"
+	case BigInt, BigIntPtr:
+		return "BigInt"
 	case IDENT:
 		return "Ident"
 	default:
@@ -866,9 +1052,9 @@ func (k Primitive) String() string {
 
 // writeStructFields is a trampoline for writeBase for
 // all of the fields in a struct
-func writeStructFields(s []StructField, name string) {
+func writeStructFields(s []StructField, name string, g *identGen) {
 	for i := range s {
-		s[i].FieldElem.SetVarname(fmt.Sprintf("%s.%s", name, s[i].FieldName))
+		s[i].FieldElem.setVarname(fmt.Sprintf("%s.%s", name, s[i].FieldName), g)
 	}
 }
 
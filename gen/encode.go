@@ -3,7 +3,6 @@ package gen
 import (
 	"fmt"
 	"io"
-	"strings"
 
 	"github.com/tinylib/msgp/msgp"
 )
@@ -71,12 +70,12 @@ func (e *encodeGen) Execute(p Elem, ctx Context) error {
 	rcv := imutMethodReceiver(p)
 	ogVar := p.Varname()
 	if p.AlwaysPtr(nil) {
-		rcv = methodReceiver(p)
+		rcv = methodReceiver(e.ctx, p)
 	}
 	e.p.printf("\nfunc (%s %s) EncodeMsg(en *msgp.Writer) (err error) {", ogVar, rcv)
 	next(e, p)
 	if p.AlwaysPtr(nil) {
-		p.SetVarname(ogVar)
+		p.setVarname(ogVar, e.ctx.idents)
 	}
 	e.p.nakedReturn()
 	return e.p.err
@@ -94,11 +93,11 @@ func (e *encodeGen) gStruct(s *Struct) {
 }
 
 func (e *encodeGen) tuple(s *Struct) {
-	nfields := len(s.Fields)
+	nfields := s.ArrayLen()
 	data := msgp.AppendArrayHeader(nil, uint32(nfields))
 	e.p.printf("\n// array header, size %d", nfields)
 	e.Fuse(data)
-	if len(s.Fields) == 0 {
+	if nfields == 0 {
 		e.fuseHook()
 	}
 	for i := range s.Fields {
@@ -242,12 +241,16 @@ func (e *encodeGen) gMap(m *Map) {
 	if !e.p.ok() {
 		return
 	}
+	if m.IsSet {
+		e.gSet(m)
+		return
+	}
 	e.fuseHook()
 	vname := m.Varname()
 	e.writeAndCheck(mapHeader, lenAsUint32, vname)
 
-	e.p.printf("\nfor %s, %s := range %s {", m.Keyidx, m.Validx, vname)
-	e.writeAndCheck(strings.Title(m.Key.TypeName()), literalFmt, m.Keyidx)
+	e.p.rangeMap(e.ctx, m)
+	e.writeAndCheck(m.KeyBaseName(), literalFmt, m.KeyToBase(m.Keyidx))
 	e.ctx.PushVar(m.Keyidx)
 	m.Value.SetIsAllowNil(false)
 	next(e, m.Value)
@@ -255,6 +258,21 @@ func (e *encodeGen) gMap(m *Map) {
 	e.p.closeblock()
 }
 
+// gSet writes the keys of a set as an array, in sorted order.
+func (e *encodeGen) gSet(m *Map) {
+	e.fuseHook()
+	if m.BoolSet() {
+		keys := e.p.setKeys(e.ctx, m)
+		e.writeAndCheck(arrayHeader, lenAsUint32, keys)
+		e.p.printf("\nfor _, %s := range %s {", m.Keyidx, keys)
+	} else {
+		e.writeAndCheck(arrayHeader, lenAsUint32, m.Varname())
+		e.p.rangeSorted(e.ctx, m)
+	}
+	e.writeAndCheck(m.KeyBaseName(), literalFmt, m.KeyToBase(m.Keyidx))
+	e.p.closeblock()
+}
+
 func (e *encodeGen) gPtr(s *Ptr) {
 	if !e.p.ok() {
 		return
@@ -300,7 +318,7 @@ func (e *encodeGen) gBase(b *BaseElem) {
 		if b.ShimMode == Cast {
 			vname = tobaseConvert(b)
 		} else {
-			vname = randIdent()
+			vname = e.ctx.randIdent()
 			e.p.printf("\nvar %s %s", vname, b.BaseType())
 			e.p.printf("\n%s, err = %s", vname, tobaseConvert(b))
 			e.p.wrapErrCheck(e.ctx.ArgsStr())
@@ -310,13 +328,25 @@ func (e *encodeGen) gBase(b *BaseElem) {
 	if b.Value == IDENT { // unknown identity
 		e.p.printf("\nerr = %s.EncodeMsg(en)", vname)
 		e.p.wrapErrCheck(e.ctx.ArgsStr())
+	} else if b.Value == BigInt || b.Value == BigIntPtr {
+		e.p.printf("\nerr = csmsgp.WriteBigInt(en, %s)", vname)
+		e.p.wrapErrCheck(e.ctx.ArgsStr())
 	} else { // typical case
 		e.writeAndCheck(b.BaseName(), literalFmt, vname)
 	}
 }
 
-func (e *encodeGen) gNilSpaceholder() {
-	e.p.printf("\nerr = en.WriteNil(); if err != nil { return; }")
+func (e *encodeGen) gNilSpaceholder(n *NilPlaceholder) {
+	if !e.p.ok() {
+		return
+	}
+	if n.Len() <= nilFuseMax {
+		e.Fuse(nilRun(n.Len()))
+		return
+	}
+	e.fuseHook()
+	e.p.printf("\nerr = csmsgp.WriteNils(en, %d)", n.Len())
+	e.p.print("\nif err != nil {\nreturn\n}") // unused indexes have no name
 }
 
 func (e *encodeGen) gCsharpString(s *CsharpString) {
@@ -324,5 +354,5 @@ func (e *encodeGen) gCsharpString(s *CsharpString) {
 		return
 	}
 	e.fuseHook()
-	e.p.printf("\n"+`if len(%s) == 0 {err = en.WriteNil(); if err != nil { return }} else { err = en.WriteString(%s); if err != nil { return }}`, s.Varname(), s.Varname())
+	e.p.printf("\n"+`if len(%s) == 0 {err = en.WriteNil(); if err != nil { return }} else { err = en.WriteString(string(%s)); if err != nil { return }}`, s.Varname(), s.Varname())
 }
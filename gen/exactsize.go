@@ -0,0 +1,326 @@
+package gen
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+func exactSizes(w io.Writer) *exactSizeGen {
+	return &exactSizeGen{
+		p:     printer{w: w},
+		state: assign,
+	}
+}
+
+// exactSizeGen prints ExactMsgsize methods.
+// Unlike sizeGen, every header and integer is sized
+// from its runtime value, so the result equals the
+// length of the MarshalMsg output.
+type exactSizeGen struct {
+	passes
+	p     printer
+	state sizeState
+	ctx   *Context
+}
+
+func (s *exactSizeGen) Method() Method { return ExactSize }
+
+func (s *exactSizeGen) Apply(dirs []string) error {
+	return nil
+}
+
+// same chaining rules as sizeGen.addConstant
+func (s *exactSizeGen) addConstant(sz string) {
+	if !s.p.ok() {
+		return
+	}
+
+	switch s.state {
+	case assign:
+		s.p.print("\ns = " + sz)
+		s.state = expr
+		return
+	case add:
+		s.p.print("\ns += " + sz)
+		s.state = expr
+		return
+	case expr:
+		s.p.print(" + " + sz)
+		return
+	}
+
+	panic("unknown size state")
+}
+
+func (s *exactSizeGen) Execute(p Elem, ctx Context) error {
+	s.ctx = &ctx
+	if !s.p.ok() {
+		return s.p.err
+	}
+	p = s.applyall(p)
+	if p == nil {
+		return nil
+	}
+	if !IsPrintable(p) {
+		return nil
+	}
+
+	s.p.comment("ExactMsgsize returns the exact number of bytes occupied by the serialized message")
+
+	rcv := imutMethodReceiver(p)
+	ogVar := p.Varname()
+	if p.AlwaysPtr(nil) {
+		rcv = methodReceiver(s.ctx, p)
+	}
+	s.p.printf("\nfunc (%s %s) ExactMsgsize() (s int) {", ogVar, rcv)
+	s.state = assign
+	next(s, p)
+	if p.AlwaysPtr(nil) {
+		p.setVarname(ogVar, s.ctx.idents)
+	}
+	s.p.nakedReturn()
+	return s.p.err
+}
+
+func (s *exactSizeGen) gStruct(st *Struct) {
+	if !s.p.ok() {
+		return
+	}
+	data := msgp.AppendArrayHeader(nil, uint32(st.ArrayLen()))
+	s.addConstant(strconv.Itoa(len(data)))
+	for i := range st.Fields {
+		if !s.p.ok() {
+			return
+		}
+		fieldElem := st.Fields[i].FieldElem
+		anField := st.Fields[i].HasTagPart("allownil") && fieldElem.AllowNil()
+		if anField {
+			s.p.printf("\nif %s { // allownil: if nil", fieldElem.IfZeroExpr())
+			s.p.print("\ns += msgp.NilSize")
+			s.p.print("\n} else {")
+			s.state = add
+		}
+		SetIsAllowNil(fieldElem, anField)
+		next(s, fieldElem)
+		if anField {
+			s.p.closeblock()
+			s.state = add
+		}
+	}
+}
+
+func (s *exactSizeGen) gPtr(p *Ptr) {
+	s.state = add
+	next(s, p.Value)
+	s.state = add
+}
+
+func (s *exactSizeGen) gSlice(sl *Slice) {
+	if !s.p.ok() {
+		return
+	}
+	s.addConstant(fmt.Sprintf("csmsgp.ArrayHeaderSize(uint32(%s))", lenExpr(sl)))
+
+	if str, ok := exactFixedExpr(sl.Els, s.ctx); ok {
+		s.addConstant(fmt.Sprintf("(%s * (%s))", lenExpr(sl), str))
+		return
+	}
+
+	s.state = add
+	s.p.rangeBlock(s.ctx, sl.Index, sl.Varname(), s, sl.Els)
+	s.state = add
+	s.p.print("\n")
+}
+
+func (s *exactSizeGen) gArray(a *Array) {
+	if !s.p.ok() {
+		return
+	}
+	// see marshalGen.gArray
+	if be, ok := a.Els.(*BaseElem); ok && be.Value == Byte {
+		s.addConstant(fmt.Sprintf("csmsgp.BytesSize(%s)", a.Size))
+		return
+	}
+
+	s.addConstant(fmt.Sprintf("csmsgp.ArrayHeaderSize(%s)", coerceArraySize(a.Size)))
+	if str, ok := exactFixedExpr(a.Els, s.ctx); ok {
+		s.addConstant(fmt.Sprintf("(%s * (%s))", a.Size, str))
+		return
+	}
+
+	s.state = add
+	s.p.rangeBlock(s.ctx, a.Index, a.Varname(), s, a.Els)
+	s.state = add
+	s.p.print("\n")
+}
+
+func (s *exactSizeGen) gMap(m *Map) {
+	if !s.p.ok() {
+		return
+	}
+	if m.IsSet {
+		s.gSet(m)
+		return
+	}
+	vn := m.Varname()
+	s.addConstant(fmt.Sprintf("csmsgp.MapHeaderSize(%s)", fmt.Sprintf(lenAsUint32, vn)))
+	s.p.printf("\nfor %s, %s := range %s {", m.Keyidx, m.Validx, vn)
+	s.p.printf("\n_, _ = %s, %s", m.Keyidx, m.Validx) // we may not use either
+	s.state = add
+	if kb, ok := m.Key.(*BaseElem); ok {
+		kb.setVarname(m.Keyidx, s.ctx.idents)
+		s.gBase(kb)
+	}
+	s.state = add
+	s.ctx.PushVar(m.Keyidx)
+	m.Value.SetIsAllowNil(false)
+	next(s, m.Value)
+	s.ctx.Pop()
+	s.p.closeblock()
+	s.state = add
+	s.p.print("\n")
+}
+
+func (s *exactSizeGen) gSet(m *Map) {
+	vn := m.Varname()
+	if m.BoolSet() {
+		s.addConstant(fmt.Sprintf("csmsgp.ArrayHeaderSize(uint32(csmsgp.SetLen(%s)))", vn))
+		s.p.printf("\nfor %s, %s := range %s {", m.Keyidx, m.Validx, vn)
+		s.p.printf("\nif !%s { continue }", m.Validx)
+	} else {
+		s.addConstant(fmt.Sprintf("csmsgp.ArrayHeaderSize(%s)", fmt.Sprintf(lenAsUint32, vn)))
+		s.p.printf("\nfor %s := range %s {", m.Keyidx, vn)
+	}
+	s.state = add
+	if kb, ok := m.Key.(*BaseElem); ok {
+		kb.setVarname(m.Keyidx, s.ctx.idents)
+		s.gBase(kb)
+	}
+	s.p.closeblock()
+	s.state = add
+	s.p.print("\n")
+}
+
+func (s *exactSizeGen) gBase(b *BaseElem) {
+	if !s.p.ok() {
+		return
+	}
+	vname := b.Varname()
+	if b.Convert {
+		if b.ShimMode == Convert {
+			s.state = add
+			tmp := s.ctx.randIdent()
+			s.p.printf("\n%s, _ := %s", tmp, tobaseConvert(b))
+			vname = tmp
+		} else {
+			vname = tobaseConvert(b)
+		}
+	}
+	s.addConstant(exactBasesizeExpr(b, vname, s.ctx))
+}
+
+func (s *exactSizeGen) gNilSpaceholder(n *NilPlaceholder) {
+	if !s.p.ok() {
+		return
+	}
+	s.addConstant(nilRunSize(n))
+}
+
+func (s *exactSizeGen) gCsharpString(cs *CsharpString) {
+	if !s.p.ok() {
+		return
+	}
+	s.p.printf("\nif len(%[1]s) == 0 { s += msgp.NilSize } else { s += csmsgp.StringSize(len(%[1]s)) }", cs.Varname())
+	s.state = add
+	s.p.print("\n")
+}
+
+// exactFixedSize reports whether every value of p
+// encodes to the same number of bytes.
+func exactFixedSize(p Primitive, ctx *Context) bool {
+	switch p {
+	case Float32, Complex64, Complex128, Bool:
+		return true
+	case Float64:
+		return !ctx.compFloats
+	case Time:
+		return !ctx.newTime
+	default:
+		return false
+	}
+}
+
+// exactFixedExpr is the ExactMsgsize counterpart of
+// fixedsizeExpr. returns (expr, ok)
+func exactFixedExpr(e Elem, ctx *Context) (string, bool) {
+	switch e := e.(type) {
+	case *Array:
+		if be, ok := e.Els.(*BaseElem); ok && be.Value == Byte {
+			return fmt.Sprintf("csmsgp.BytesSize(%s)", e.Size), true
+		}
+		if str, ok := exactFixedExpr(e.Els, ctx); ok {
+			return fmt.Sprintf("csmsgp.ArrayHeaderSize(%s) + (%s * (%s))", coerceArraySize(e.Size), e.Size, str), true
+		}
+	case *BaseElem:
+		if exactFixedSize(e.Value, ctx) {
+			return builtinSize(e.BaseName()), true
+		}
+	case *NilPlaceholder:
+		return nilRunSize(e), true
+	case *Struct:
+		str := strconv.Itoa(len(msgp.AppendArrayHeader(nil, uint32(e.ArrayLen()))))
+		for _, f := range e.Fields {
+			fs, ok := exactFixedExpr(f.FieldElem, ctx)
+			if !ok {
+				return "", false
+			}
+			str += " + " + fs
+		}
+		return str, true
+	}
+	return "", false
+}
+
+// print exact size expression of a variable name
+func exactBasesizeExpr(b *BaseElem, vname string, ctx *Context) string {
+	switch b.Value {
+	case Ext:
+		return "csmsgp.ExtensionSize(" + stripRef(vname) + ".Len())"
+	case Intf:
+		return "csmsgp.IntfSize(" + vname + ")"
+	case BigInt, BigIntPtr:
+		return "csmsgp.BigIntSize(" + vname + ")"
+	case JsonNumber:
+		return "csmsgp.JSONNumberSize(" + vname + ")"
+	case IDENT:
+		switch b.TypeName() {
+		case "msgp.Raw":
+			// Raw.Msgsize is already exact
+			return vname + ".Msgsize()"
+		}
+		if hasExactMsgsize(b) || !b.Resolved() {
+			return vname + ".ExactMsgsize()"
+		}
+		return "csmsgp.MarshaledSize(" + vname + ")"
+	case Bytes:
+		return "csmsgp.BytesSize(len(" + vname + "))"
+	case String:
+		return "csmsgp.StringSize(len(" + vname + "))"
+	case Int, Int8, Int16, Int32, Int64, Duration:
+		return "csmsgp.IntSize(int64(" + vname + "))"
+	case Uint, Uint8, Uint16, Uint32, Uint64, Byte:
+		return "csmsgp.UintSize(uint64(" + vname + "))"
+	case Float64:
+		if ctx.compFloats {
+			return "csmsgp.FloatSize(" + vname + ")"
+		}
+	case Time:
+		if ctx.newTime {
+			return "csmsgp.TimeExtSize(" + vname + ")"
+		}
+	}
+	return builtinSize(b.BaseName())
+}
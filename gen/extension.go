@@ -0,0 +1,77 @@
+package gen
+
+import (
+	"io"
+)
+
+func extensions(w io.Writer, exact bool) *extensionGen {
+	return &extensionGen{
+		p:     printer{w: w},
+		exact: exact,
+	}
+}
+
+// extensionGen prints the msgp.Extension methods for
+// types declared with //msgp:ext, and registers them
+// with msgp.RegisterExtension.
+//
+// The extension body is the type's own MarshalMsg output,
+// so it relies on the Marshal and Unmarshal passes.
+type extensionGen struct {
+	passes
+	p     printer
+	ctx   *Context
+	exact bool // ExactMsgsize is generated
+}
+
+func (e *extensionGen) Method() Method { return Marshal | Unmarshal }
+
+func (e *extensionGen) Apply(dirs []string) error {
+	return nil
+}
+
+func (e *extensionGen) Execute(p Elem, ctx Context) error {
+	e.ctx = &ctx
+	if !e.p.ok() {
+		return e.p.err
+	}
+	p = e.applyall(p)
+	if p == nil {
+		return nil
+	}
+	if !IsPrintable(p) {
+		return nil
+	}
+	name := p.TypeName()
+	code, ok := ctx.exts[name]
+	if !ok {
+		return nil
+	}
+	vn := p.Varname()
+
+	e.p.comment("ExtensionType implements msgp.Extension")
+	e.p.printf("\nfunc (%s *%s) ExtensionType() int8 { return %d }", vn, name, code)
+
+	e.p.comment("Len implements msgp.Extension")
+	if e.exact {
+		e.p.printf("\nfunc (%s *%s) Len() int { return %s.ExactMsgsize() }", vn, name, vn)
+	} else {
+		e.p.printf("\nfunc (%s *%s) Len() int { return csmsgp.MarshaledSize(%s) }", vn, name, vn)
+	}
+
+	e.p.comment("MarshalBinaryTo implements msgp.Extension")
+	e.p.printf("\nfunc (%s *%s) MarshalBinaryTo(b []byte) (err error) {", vn, name)
+	// MarshalMsg grows by Msgsize, which may exceed len(b)
+	// and move the output to a new array; copy it back.
+	e.p.printf("\nvar o []byte\no, err = %s.MarshalMsg(b[:0])", vn)
+	e.p.print("\ncopy(b, o)")
+	e.p.nakedReturn()
+
+	e.p.comment("UnmarshalBinary implements msgp.Extension")
+	e.p.printf("\nfunc (%s *%s) UnmarshalBinary(b []byte) (err error) {", vn, name)
+	e.p.printf("\n_, err = %s.UnmarshalMsg(b)", vn)
+	e.p.nakedReturn()
+
+	e.p.printf("\n\nfunc init() {\nmsgp.RegisterExtension(%d, func() msgp.Extension { return new(%s) })\n}\n", code, name)
+	return e.p.err
+}
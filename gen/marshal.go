@@ -3,7 +3,6 @@ package gen
 import (
 	"fmt"
 	"io"
-	"strings"
 
 	"github.com/tinylib/msgp/msgp"
 )
@@ -49,19 +48,41 @@ func (m *marshalGen) Execute(p Elem, ctx Context) error {
 	rcv := imutMethodReceiver(p)
 	ogVar := p.Varname()
 	if p.AlwaysPtr(nil) {
-		rcv = methodReceiver(p)
+		rcv = methodReceiver(m.ctx, p)
 	}
 	m.p.printf("\nfunc (%s %s) MarshalMsg(b []byte) (o []byte, err error) {", ogVar, rcv)
 	m.p.printf("\no = msgp.Require(b, %s.Msgsize())", c)
 	next(m, p)
 	if p.AlwaysPtr(nil) {
-		p.SetVarname(ogVar)
+		p.setVarname(ogVar, m.ctx.idents)
 	}
 
 	m.p.nakedReturn()
+
+	if st, ok := p.(*Struct); ok {
+		for i := range st.Fields {
+			if typ, ok := st.Fields[i].TagPartValue("lazy"); ok {
+				m.lazy(st, i, typ)
+			}
+		}
+	}
 	return m.p.err
 }
 
+// lazy prints Set{{Field}} for a msgp.Raw field that
+// holds a serialized typ.
+func (m *marshalGen) lazy(s *Struct, i int, typ string) {
+	if !m.p.ok() {
+		return
+	}
+	sf := s.Fields[i]
+	m.p.printf("\n\n// Set%s stores v in the lazily kept %s field (index %d).", sf.FieldName, sf.FieldName, sf.FieldTag)
+	m.p.printf("\nfunc (z *%s) Set%s(v *%s) (err error) {", s.TypeName(), sf.FieldName, typ)
+	m.p.printf("\nz.%[1]s, err = v.MarshalMsg(z.%[1]s[:0])", sf.FieldName)
+	m.p.wrapErrCheck(fmt.Sprintf("%q", sf.FieldName))
+	m.p.nakedReturn()
+}
+
 func (m *marshalGen) rawAppend(typ string, argfmt string, arg interface{}) {
 	if m.ctx.compFloats && typ == "Float64" {
 		typ = "Float"
@@ -102,17 +123,18 @@ func (m *marshalGen) gStruct(s *Struct) {
 
 func (m *marshalGen) tuple(s *Struct) {
 	data := make([]byte, 0, 5)
-	data = msgp.AppendArrayHeader(data, uint32(len(s.Fields)))
-	m.p.printf("\n// array header, size %d", len(s.Fields))
+	nfields := s.ArrayLen()
+	data = msgp.AppendArrayHeader(data, uint32(nfields))
+	m.p.printf("\n// array header, size %d", nfields)
 	m.Fuse(data)
-	if len(s.Fields) == 0 {
+	if nfields == 0 {
 		m.fuseHook()
 	}
 	for i := range s.Fields {
 		if !m.p.ok() {
 			return
 		}
-		m.p.printf("\n// idx %d", i)
+		m.p.printf("\n// idx %d", s.Fields[i].FieldTag)
 		fieldElem := s.Fields[i].FieldElem
 		anField := s.Fields[i].HasTagPart("allownil") && fieldElem.AllowNil()
 		if anField {
@@ -249,11 +271,15 @@ func (m *marshalGen) gMap(s *Map) {
 	if !m.p.ok() {
 		return
 	}
+	if s.IsSet {
+		m.gSet(s)
+		return
+	}
 	m.fuseHook()
 	vname := s.Varname()
 	m.rawAppend(mapHeader, lenAsUint32, vname)
-	m.p.printf("\nfor %s, %s := range %s {", s.Keyidx, s.Validx, vname)
-	m.rawAppend(strings.Title(s.Key.TypeName()), literalFmt, s.Keyidx)
+	m.p.rangeMap(m.ctx, s)
+	m.rawAppend(s.KeyBaseName(), literalFmt, s.KeyToBase(s.Keyidx))
 	m.ctx.PushVar(s.Keyidx)
 	s.Value.SetIsAllowNil(false)
 	next(m, s.Value)
@@ -261,6 +287,21 @@ func (m *marshalGen) gMap(s *Map) {
 	m.p.closeblock()
 }
 
+// gSet appends the keys of a set as an array, in sorted order.
+func (m *marshalGen) gSet(s *Map) {
+	m.fuseHook()
+	if s.BoolSet() {
+		keys := m.p.setKeys(m.ctx, s)
+		m.rawAppend(arrayHeader, lenAsUint32, keys)
+		m.p.printf("\nfor _, %s := range %s {", s.Keyidx, keys)
+	} else {
+		m.rawAppend(arrayHeader, lenAsUint32, s.Varname())
+		m.p.rangeSorted(m.ctx, s)
+	}
+	m.rawAppend(s.KeyBaseName(), literalFmt, s.KeyToBase(s.Keyidx))
+	m.p.closeblock()
+}
+
 func (m *marshalGen) gSlice(s *Slice) {
 	if !m.p.ok() {
 		return
@@ -305,7 +346,7 @@ func (m *marshalGen) gBase(b *BaseElem) {
 		if b.ShimMode == Cast {
 			vname = tobaseConvert(b)
 		} else {
-			vname = randIdent()
+			vname = m.ctx.randIdent()
 			m.p.printf("\nvar %s %s", vname, b.BaseType())
 			m.p.printf("\n%s, err = %s", vname, tobaseConvert(b))
 			m.p.wrapErrCheck(m.ctx.ArgsStr())
@@ -320,6 +361,8 @@ func (m *marshalGen) gBase(b *BaseElem) {
 	case Intf, Ext, JsonNumber:
 		echeck = true
 		m.p.printf("\no, err = msgp.Append%s(o, %s)", b.BaseName(), vname)
+	case BigInt, BigIntPtr:
+		m.p.printf("\no = csmsgp.AppendBigInt(o, %s)", vname)
 	default:
 		m.rawAppend(b.BaseName(), literalFmt, vname)
 	}
@@ -329,12 +372,16 @@ func (m *marshalGen) gBase(b *BaseElem) {
 	}
 }
 
-func (m *marshalGen) gNilSpaceholder() {
+func (m *marshalGen) gNilSpaceholder(n *NilPlaceholder) {
 	if !m.p.ok() {
 		return
 	}
+	if n.Len() <= nilFuseMax {
+		m.Fuse(nilRun(n.Len()))
+		return
+	}
 	m.fuseHook()
-	m.p.printf("\no = msgp.AppendNil(o)")
+	m.p.printf("\no = csmsgp.AppendNils(o, %d)", n.Len())
 }
 
 func (m *marshalGen) gCsharpString(s *CsharpString) {
@@ -1,10 +1,10 @@
 package gen
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"strconv"
-	"strings"
 
 	"github.com/tinylib/msgp/msgp"
 )
@@ -90,13 +90,13 @@ func (s *sizeGen) Execute(p Elem, ctx Context) error {
 	rcv := imutMethodReceiver(p)
 	ogVar := p.Varname()
 	if p.AlwaysPtr(nil) {
-		rcv = methodReceiver(p)
+		rcv = methodReceiver(s.ctx, p)
 	}
 	s.p.printf("\nfunc (%s %s) Msgsize() (s int) {", ogVar, rcv)
 	s.state = assign
 	next(s, p)
 	if p.AlwaysPtr(nil) {
-		p.SetVarname(ogVar)
+		p.setVarname(ogVar, s.ctx.idents)
 	}
 	s.p.nakedReturn()
 	return s.p.err
@@ -107,7 +107,7 @@ func (s *sizeGen) gStruct(st *Struct) {
 		return
 	}
 
-	nfields := uint32(len(st.Fields))
+	nfields := uint32(st.ArrayLen())
 
 	//	if st.AsTuple {
 	data := msgp.AppendArrayHeader(nil, nfields)
@@ -116,10 +116,9 @@ func (s *sizeGen) gStruct(st *Struct) {
 		if !s.p.ok() {
 			return
 		}
-		s.p.printf("/* idx %d */", i)
+		s.p.printf("/* idx %d */", st.Fields[i].FieldTag)
 		next(s, st.Fields[i].FieldElem)
 	}
-	s.p.print("\n")
 	// } else {
 	// 	data := msgp.AppendMapHeader(nil, nfields)
 	// 	s.addConstant(strconv.Itoa(len(data)))
@@ -184,16 +183,20 @@ func (s *sizeGen) gArray(a *Array) {
 }
 
 func (s *sizeGen) gMap(m *Map) {
+	if m.IsSet {
+		s.gSet(m)
+		return
+	}
 	s.addConstant(builtinSize(mapHeader))
 	vn := m.Varname()
 	s.p.printf("\nif %s != nil {", vn)
 	s.p.printf("\nfor %s, %s := range %s {", m.Keyidx, m.Validx, vn)
 	s.p.printf("\n_ = %s", m.Validx) // we may not use the value
-	if m.Key.TypeName() == "string" {
+	if m.KeyBaseType() == "string" {
 		s.p.printf("\ns += msgp.StringPrefixSize + len(%s)", m.Keyidx)
 	} else {
 		s.p.printf("\n_ = %s", m.Keyidx) // we may not use the value
-		s.p.printf("\ns += msgp.%sSize", strings.Title(m.Key.TypeName()))
+		s.p.printf("\ns += msgp.%sSize", m.KeyBaseName())
 	}
 	s.state = expr
 	s.ctx.PushVar(m.Keyidx)
@@ -206,13 +209,32 @@ func (s *sizeGen) gMap(m *Map) {
 
 }
 
+// gSet counts every key; keys of a map[K]bool set with a false
+// value aren't written, so this stays an upper bound.
+func (s *sizeGen) gSet(m *Map) {
+	s.addConstant(builtinSize(arrayHeader))
+	vn := m.Varname()
+	if m.KeyBaseType() == "string" {
+		s.p.printf("\nfor %s := range %s {", m.Keyidx, vn)
+		s.p.printf("\ns += msgp.StringPrefixSize + len(%s)", m.Keyidx)
+		s.p.closeblock()
+	} else {
+		s.p.printf("\ns += len(%s) * msgp.%sSize", vn, m.KeyBaseName())
+	}
+	s.state = add
+	s.p.print("\n")
+}
+
 func (s *sizeGen) gBase(b *BaseElem) {
 	if !s.p.ok() {
 		return
 	}
-	if b.Convert && b.ShimMode == Convert {
+	if b.Convert && b.ShimMode == Convert && fixedSize(b.Value) {
+		// the converted value does not change the size
+		s.addConstant(builtinSize(b.BaseName()))
+	} else if b.Convert && b.ShimMode == Convert {
 		s.state = add
-		vname := randIdent()
+		vname := s.ctx.randIdent()
 		s.p.printf("\nvar %s %s", vname, b.BaseType())
 
 		// ensure we don't get "unused variable" warnings from outer slice iterations
@@ -230,13 +252,11 @@ func (s *sizeGen) gBase(b *BaseElem) {
 	}
 }
 
-func (s *sizeGen) gNilSpaceholder() {
+func (s *sizeGen) gNilSpaceholder(n *NilPlaceholder) {
 	if !s.p.ok() {
 		return
 	}
-	//s.p.printf("\ns += msgp.NilSize")
-	s.addConstant(builtinSize("Nil"))
-	//s.state = expr
+	s.addConstant(nilRunSize(n))
 }
 
 func (s *sizeGen) gCsharpString(cs *CsharpString) {
@@ -257,7 +277,7 @@ func lenExpr(sl *Slice) string {
 // size on the wire?
 func fixedSize(p Primitive) bool {
 	switch p {
-	case Intf, Ext, IDENT, Bytes, String:
+	case Intf, Ext, IDENT, Bytes, String, BigInt, BigIntPtr:
 		return false
 	default:
 		return true
@@ -300,7 +320,7 @@ func fixedsizeExpr(e Elem) (string, bool) {
 		}
 		var hdrlen int
 		//mhdr := msgp.AppendMapHeader(nil, uint32(len(e.Fields)))
-		mhdr := msgp.AppendArrayHeader(nil, uint32(len(e.Fields)))
+		mhdr := msgp.AppendArrayHeader(nil, uint32(e.ArrayLen()))
 		hdrlen += len(mhdr)
 		// var strbody []byte
 		// for _, f := range e.Fields {
@@ -319,6 +339,8 @@ func basesizeExpr(value Primitive, vname, basename string) string {
 		return "msgp.ExtensionPrefixSize + " + stripRef(vname) + ".Len()"
 	case Intf:
 		return "msgp.GuessSize(" + vname + ")"
+	case BigInt, BigIntPtr:
+		return "csmsgp.BigIntSize(" + vname + ")"
 	case IDENT:
 		return vname + ".Msgsize()"
 	case Bytes:
@@ -329,3 +351,16 @@ func basesizeExpr(value Primitive, vname, basename string) string {
 		return builtinSize(basename)
 	}
 }
+
+// nilRun returns the bytes of n nils.
+func nilRun(n int) []byte {
+	return bytes.Repeat(msgp.AppendNil(nil), n)
+}
+
+// nilRunSize returns the size expression of a run of nils.
+func nilRunSize(n *NilPlaceholder) string {
+	if n.Len() == 1 {
+		return builtinSize("Nil")
+	}
+	return fmt.Sprintf("%d*%s", n.Len(), builtinSize("Nil"))
+}
@@ -9,6 +9,8 @@ import (
 
 const (
 	lenAsUint32 = "uint32(len(%s))"
+	setSortBuf  = 16 // keys sorted on the stack before SortedKeys allocates
+	nilFuseMax  = 16 // longer nil runs are written in a loop
 	literalFmt  = "%s"
 	intFmt      = "%d"
 	quotedFmt   = `"%s"`
@@ -21,7 +23,7 @@ const (
 
 // Method is a bitfield representing something that the
 // generator knows how to print.
-type Method uint8
+type Method uint16
 
 // are the bits in 'f' set in 'm'?
 func (m Method) isset(f Method) bool { return (m&f == f) }
@@ -43,9 +45,15 @@ func (m Method) String() string {
 		return "size"
 	case Test:
 		return "test"
+	case ExactSize:
+		return "exactsize"
+	case Stream:
+		return "stream"
+	case Diff:
+		return "diff"
 	default:
 		// return e.g. "decode+encode+test"
-		modes := [...]Method{Decode, Encode, Marshal, Unmarshal, Size, Test}
+		modes := [...]Method{Decode, Encode, Marshal, Unmarshal, Size, Test, ExactSize, Stream, Diff}
 		any := false
 		nm := ""
 		for _, mm := range modes {
@@ -64,15 +72,19 @@ func (m Method) String() string {
 }
 
 const (
-	Decode      Method                       = 1 << iota // msgp.Decodable
-	Encode                                               // msgp.Encodable
-	Marshal                                              // msgp.Marshaler
-	Unmarshal                                            // msgp.Unmarshaler
-	Size                                                 // msgp.Sizer
-	Test                                                 // generate tests
-	invalidmeth                                          // this isn't a method
-	encodetest  = Encode | Decode | Test                 // tests for Encodable and Decodable
-	marshaltest = Marshal | Unmarshal | Test             // tests for Marshaler and Unmarshaler
+	Decode        Method                       = 1 << iota // msgp.Decodable
+	Encode                                                 // msgp.Encodable
+	Marshal                                                // msgp.Marshaler
+	Unmarshal                                              // msgp.Unmarshaler
+	Size                                                   // msgp.Sizer
+	Test                                                   // generate tests
+	ExactSize                                              // ExactMsgsize method
+	Stream                                                 // streaming Decode/Encode{{Type}}Each funcs
+	Diff                                                   // Diff and ApplyPatch methods
+	invalidmeth                                            // this isn't a method
+	encodetest    = Encode | Decode | Test                 // tests for Encodable and Decodable
+	marshaltest   = Marshal | Unmarshal | Test             // tests for Marshaler and Unmarshaler
+	exactsizetest = ExactSize | Marshal | Test             // tests for ExactMsgsize
 )
 
 type Printer struct {
@@ -80,13 +92,30 @@ type Printer struct {
 	CompactFloats bool
 	ClearOmitted  bool
 	NewTime       bool
+	Deterministic bool            // write map keys in sorted order
+	Structs       map[string]bool // names of the struct types being printed
+	Exts          map[string]int8 // extension type codes declared with //msgp:ext
+	MaxLen        uint32          // max decoded array and map length, or 0 for no limit
+	Types         map[string]TypeOptions
+	idents        identGen
+}
+
+// TypeOptions are printer options set for
+// a single type from its doc comment. They
+// add to the options of the Printer.
+type TypeOptions struct {
+	CompactFloats bool
+	ClearOmitted  bool
+	NewTime       bool
+	Deterministic bool
+	MaxLen        uint32
 }
 
 func NewPrinter(m Method, out io.Writer, tests io.Writer) *Printer {
 	if m.isset(Test) && tests == nil {
 		panic("cannot print tests with 'nil' tests argument!")
 	}
-	gens := make([]generator, 0, 7)
+	gens := make([]generator, 0, 10)
 	if m.isset(Decode) {
 		gens = append(gens, decode(out))
 	}
@@ -102,12 +131,27 @@ func NewPrinter(m Method, out io.Writer, tests io.Writer) *Printer {
 	if m.isset(Size) {
 		gens = append(gens, sizes(out))
 	}
+	if m.isset(ExactSize) {
+		gens = append(gens, exactSizes(out))
+	}
+	if m.isset(Marshal | Unmarshal) {
+		gens = append(gens, extensions(out, m.isset(ExactSize)))
+	}
+	if m.isset(Stream) {
+		gens = append(gens, stream(out))
+	}
+	if m.isset(Diff) {
+		gens = append(gens, diff(out))
+	}
 	if m.isset(marshaltest) {
 		gens = append(gens, mtest(tests))
 	}
 	if m.isset(encodetest) {
 		gens = append(gens, etest(tests))
 	}
+	if m.isset(exactsizetest) {
+		gens = append(gens, xtest(tests))
+	}
 	if len(gens) == 0 {
 		panic("NewPrinter called with invalid method flags")
 	}
@@ -143,18 +187,28 @@ func (p *Printer) ApplyDirective(pass Method, t TransformPass) {
 // Print prints an Elem.
 func (p *Printer) Print(e Elem) error {
 	e.SetIsAllowNil(false)
+	p.idents.reset("za")
+	e.setVarname("z", &p.idents)
 	for _, g := range p.gens {
-		// Elem.SetVarname() is called before the Print() step in parse.FileSet.PrintTo().
-		// Elem.SetVarname() generates identifiers as it walks the Elem. This can cause
-		// collisions between idents created during SetVarname and idents created during Print,
+		// Elem.setVarname() generates identifiers as it walks the Elem. This can cause
+		// collisions between idents created during setVarname and idents created during Print,
 		// hence the separate prefixes.
-		resetIdent("zb")
+		p.idents.reset("zb")
+		opts := p.Types[e.TypeName()]
+		maxLen := p.MaxLen
+		if opts.MaxLen != 0 {
+			maxLen = opts.MaxLen
+		}
 		err := g.Execute(e, Context{
-			compFloats:   p.CompactFloats,
-			clearOmitted: p.ClearOmitted,
-			newTime:      p.NewTime,
+			compFloats:    p.CompactFloats || opts.CompactFloats,
+			clearOmitted:  p.ClearOmitted || opts.ClearOmitted,
+			newTime:       p.NewTime || opts.NewTime,
+			deterministic: p.Deterministic || opts.Deterministic,
+			structs:       p.Structs,
+			exts:          p.Exts,
+			maxLen:        maxLen,
+			idents:        &p.idents,
 		})
-		resetIdent("za")
 
 		if err != nil {
 			return err
@@ -180,12 +234,20 @@ func (c contextVar) Arg() string {
 }
 
 type Context struct {
-	path         []contextItem
-	compFloats   bool
-	clearOmitted bool
-	newTime      bool
+	path          []contextItem
+	compFloats    bool
+	clearOmitted  bool
+	newTime       bool
+	deterministic bool
+	structs       map[string]bool
+	exts          map[string]int8
+	maxLen        uint32
+	idents        *identGen
 }
 
+// randIdent returns a new identifier for generated code.
+func (c *Context) randIdent() string { return c.idents.randIdent() }
+
 func (c *Context) PushString(s string) {
 	c.path = append(c.path, contextString(s))
 }
@@ -240,7 +302,7 @@ type traversal interface {
 	gPtr(*Ptr)
 	gBase(*BaseElem)
 	gStruct(*Struct)
-	gNilSpaceholder()
+	gNilSpaceholder(*NilPlaceholder)
 	gCsharpString(*CsharpString)
 }
 
@@ -261,7 +323,7 @@ func next(t traversal, e Elem) {
 	case *BaseElem:
 		t.gBase(e)
 	case *NilPlaceholder:
-		t.gNilSpaceholder()
+		t.gNilSpaceholder(e)
 	case *CsharpString:
 		t.gCsharpString(e)
 	default:
@@ -299,7 +361,7 @@ func imutMethodReceiver(p Elem) string {
 // if necessary, wraps a type
 // so that its method receiver
 // is of the write type.
-func methodReceiver(p Elem) string {
+func methodReceiver(ctx *Context, p Elem) string {
 	switch p.(type) {
 
 	// structs and arrays are
@@ -310,16 +372,16 @@ func methodReceiver(p Elem) string {
 	// set variable name to
 	// *varname
 	default:
-		p.SetVarname("(*" + p.Varname() + ")")
+		p.setVarname("(*"+p.Varname()+")", ctx.idents)
 		return "*" + p.TypeName()
 	}
 }
 
-func unsetReceiver(p Elem) {
+func unsetReceiver(ctx *Context, p Elem) {
 	switch p.(type) {
 	case *Struct, *Array:
 	default:
-		p.SetVarname("z")
+		p.setVarname("z", ctx.idents)
 	}
 }
 
@@ -359,7 +421,61 @@ func (p *printer) mapAssign(m *Map) {
 	if !p.ok() {
 		return
 	}
-	p.printf("\n%s[%s] = %s", m.Varname(), m.Keyidx, m.Validx)
+	p.printf("\n%s[%s] = %s", m.Varname(), m.KeyFromBase(m.Keyidx), m.Validx)
+}
+
+// add the key to a set
+func (p *printer) setAssign(m *Map) {
+	if !p.ok() {
+		return
+	}
+	p.printf("\n%s[%s] = %s", m.Varname(), m.KeyFromBase(m.Keyidx), m.SetElemExpr())
+}
+
+// does:
+//
+//	var buf [setSortBuf]K
+//	for _, k := range csmsgp.SortedKeys(buf[:0], m) {
+//
+// the caller closes the block.
+func (p *printer) rangeSorted(ctx *Context, m *Map) {
+	buf := ctx.randIdent()
+	p.printf("\nvar %s [%d]%s", buf, setSortBuf, m.Key.TypeName())
+	p.printf("\nfor _, %s := range csmsgp.SortedKeys(%s[:0], %s) {", m.Keyidx, buf, m.Varname())
+}
+
+// does:
+//
+//	var buf [setSortBuf]K
+//	keys := csmsgp.SetKeys(buf[:0], m)
+//
+// and returns keys, the sorted keys of the map[K]bool set m
+// whose value is true.
+func (p *printer) setKeys(ctx *Context, m *Map) string {
+	buf, keys := ctx.randIdent(), ctx.randIdent()
+	p.printf("\nvar %s [%d]%s", buf, setSortBuf, m.Key.TypeName())
+	p.printf("\n%s := csmsgp.SetKeys(%s[:0], %s)", keys, buf, m.Varname())
+	return keys
+}
+
+// does:
+//
+//	for k, v := range m {
+//
+// or, when the keys are sorted:
+//
+//	var buf [setSortBuf]K
+//	for _, k := range csmsgp.SortedKeys(buf[:0], m) {
+//	v := m[k]
+//
+// the caller closes the block.
+func (p *printer) rangeMap(ctx *Context, m *Map) {
+	if m.Sorted || ctx.deterministic {
+		p.rangeSorted(ctx, m)
+		p.printf("\n%s := %s[%s]", m.Validx, m.Varname(), m.Keyidx)
+		return
+	}
+	p.printf("\nfor %s, %s := range %s {", m.Keyidx, m.Validx, m.Varname())
 }
 
 // clear map keys
@@ -385,10 +501,29 @@ func (p *printer) resizeSliceNoNil(size string, s *Slice) {
 	p.printf("\n} else { %[1]s = make(%[3]s, %[2]s) }", s.Varname(), size, s.TypeName())
 }
 
+// zeroValue resets a struct or array to its zero value.
+// The method receiver "z" is a pointer and is dereferenced.
+func (p *printer) zeroValue(e Elem) {
+	vn := e.Varname()
+	if vn == "z" {
+		vn = "*z"
+	}
+	p.printf("\n%s = %s{}", vn, e.TypeName())
+}
+
 func (p *printer) arrayCheck(want string, got string) {
 	p.printf("\nif %[1]s != %[2]s { err = msgp.ArrayError{Wanted: %[2]s, Got: %[1]s}; return }", got, want)
 }
 
+// maxLenCheck rejects a decoded array or map
+// length sz above the //msgp:maxlen limit, if any.
+func (p *printer) maxLenCheck(sz string, ctx *Context) {
+	if ctx.maxLen == 0 {
+		return
+	}
+	p.printf("\nif %[1]s > %[2]d {\nerr = msgp.WrapError(csmsgp.LengthError{Max: %[2]d, Got: %[1]s}, %[3]s)\nreturn\n}", sz, ctx.maxLen, ctx.ArgsStr())
+}
+
 func (p *printer) closeblock() { p.print("\n}") }
 
 // does:
@@ -0,0 +1,114 @@
+package gen
+
+import (
+	"io"
+)
+
+func stream(w io.Writer) *streamGen {
+	return &streamGen{
+		p: printer{w: w},
+	}
+}
+
+// streamGen prints Decode{{Type}}Each and Encode{{Type}}Each
+// for slice types, so that large top-level arrays can be
+// processed one element at a time.
+type streamGen struct {
+	passes
+	p   printer
+	ctx *Context
+}
+
+func (s *streamGen) Method() Method { return Stream }
+
+func (s *streamGen) Apply(dirs []string) error {
+	return nil
+}
+
+func (s *streamGen) Execute(p Elem, ctx Context) error {
+	s.ctx = &ctx
+	if !s.p.ok() {
+		return s.p.err
+	}
+	p = s.applyall(p)
+	if p == nil {
+		return nil
+	}
+	if !IsPrintable(p) {
+		return nil
+	}
+	sl, ok := p.(*Slice)
+	if !ok {
+		return nil
+	}
+
+	s.decodeEach(sl)
+	if !s.p.ok() {
+		return s.p.err
+	}
+	s.encodeEach(sl)
+	return s.p.err
+}
+
+func (s *streamGen) decodeEach(sl *Slice) {
+	name := sl.TypeName()
+	el := sl.Els.Copy()
+	elTyp := el.TypeName()
+
+	s.p.printf("\n// Decode%sEach reads a %s from dc one element at a time", name, name)
+	s.p.printf("\n// and calls fn with each element instead of materializing the whole slice.")
+	s.p.printf("\n// The element passed to fn is reused between calls; copy it to retain it.")
+	s.p.printf("\nfunc Decode%sEach(dc *msgp.Reader, fn func(*%s) error) (err error) {", name, elTyp)
+
+	sz := s.ctx.randIdent()
+	idx := s.ctx.randIdent()
+	vn := s.ctx.randIdent()
+	el.setVarname(vn, s.ctx.idents)
+
+	d := decode(s.p.w)
+	d.ctx = s.ctx
+	d.p.declare(sz, u32)
+	d.assignAndCheck(sz, arrayHeader)
+	d.p.declare(vn, elTyp)
+	d.p.printf("\nfor %[1]s := uint32(0); %[1]s < %[2]s; %[1]s++ {", idx, sz)
+	s.ctx.PushVar(idx)
+	el.SetIsAllowNil(false)
+	next(d, el)
+	s.ctx.Pop()
+	d.p.printf("\nerr = fn(&%s)", vn)
+	d.p.print("\nif err != nil {\nreturn\n}")
+	d.p.closeblock()
+	d.p.nakedReturn()
+	s.p.err = d.p.err
+}
+
+func (s *streamGen) encodeEach(sl *Slice) {
+	name := sl.TypeName()
+	el := sl.Els.Copy()
+	elTyp := el.TypeName()
+
+	s.p.printf("\n// Encode%sEach writes a %s of n elements to en without building the slice.", name, name)
+	s.p.printf("\n// fn is called n times to fill the element that is written next;")
+	s.p.printf("\n// the element still holds the previous value when fn is called.")
+	s.p.printf("\nfunc Encode%sEach(en *msgp.Writer, n uint32, fn func(*%s) error) (err error) {", name, elTyp)
+
+	idx := s.ctx.randIdent()
+	vn := s.ctx.randIdent()
+	el.setVarname(vn, s.ctx.idents)
+
+	e := encode(s.p.w)
+	e.ctx = s.ctx
+	e.writeAndCheck(arrayHeader, literalFmt, "n")
+	e.p.declare(vn, elTyp)
+	e.p.printf("\nfor %[1]s := uint32(0); %[1]s < n; %[1]s++ {", idx)
+	e.p.printf("\nerr = fn(&%s)", vn)
+	e.p.print("\nif err != nil {\nreturn\n}")
+	s.ctx.PushVar(idx)
+	el.SetIsAllowNil(false)
+	next(e, el)
+	e.fuseHook()
+	s.ctx.Pop()
+	e.p.closeblock()
+	e.p.nakedReturn()
+	s.p.err = e.p.err
+}
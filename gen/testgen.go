@@ -6,8 +6,9 @@ import (
 )
 
 var (
-	marshalTestTempl = template.New("MarshalTest")
-	encodeTestTempl  = template.New("EncodeTest")
+	marshalTestTempl   = template.New("MarshalTest")
+	encodeTestTempl    = template.New("EncodeTest")
+	exactSizeTestTempl = template.New("ExactSizeTest")
 )
 
 // TODO(philhofer):
@@ -61,6 +62,28 @@ func (e *etestGen) Execute(p Elem, _ Context) error {
 
 func (e *etestGen) Method() Method { return encodetest }
 
+type xtestGen struct {
+	passes
+	w io.Writer
+}
+
+func xtest(w io.Writer) *xtestGen {
+	return &xtestGen{w: w}
+}
+
+func (x *xtestGen) Execute(p Elem, _ Context) error {
+	p = x.applyall(p)
+	if p != nil && IsPrintable(p) {
+		switch p.(type) {
+		case *Struct, *Array, *Slice, *Map:
+			return exactSizeTestTempl.Execute(x.w, p)
+		}
+	}
+	return nil
+}
+
+func (x *xtestGen) Method() Method { return exactsizetest }
+
 func init() {
 	template.Must(marshalTestTempl.Parse(`func TestMarshalUnmarshal{{.TypeName}}(t *testing.T) {
 	v := {{.TypeName}}{}
@@ -177,5 +200,21 @@ func BenchmarkDecode{{.TypeName}}(b *testing.B) {
 	}
 }
 
+`))
+	template.Must(exactSizeTestTempl.Parse(`func TestExactMsgsize{{.TypeName}}(t *testing.T) {
+	// lengths and values on either side of the size classes
+	for _, n := range []int{0, 1, 15, 16, 31, 32, 127, 128, 255, 256, 65535, 65536} {
+		v := {{.TypeName}}{}
+		csmsgp.Fill(&v, n)
+		bts, err := v.MarshalMsg(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(bts) != v.ExactMsgsize() {
+			t.Errorf("n=%d: ExactMsgsize() = %d, MarshalMsg wrote %d bytes", n, v.ExactMsgsize(), len(bts))
+		}
+	}
+}
+
 `))
 }
@@ -1,9 +1,9 @@
 package gen
 
 import (
+	"fmt"
 	"io"
 	"strconv"
-	"strings"
 )
 
 func unmarshal(w io.Writer) *unmarshalGen {
@@ -45,14 +45,81 @@ func (u *unmarshalGen) Execute(p Elem, ctx Context) error {
 
 	u.p.comment("UnmarshalMsg implements msgp.Unmarshaler")
 
-	u.p.printf("\nfunc (%s %s) UnmarshalMsg(bts []byte) (o []byte, err error) {", p.Varname(), methodReceiver(p))
+	u.p.printf("\nfunc (%s %s) UnmarshalMsg(bts []byte) (o []byte, err error) {", p.Varname(), methodReceiver(u.ctx, p))
 	next(u, p)
 	u.p.print("\no = bts")
 	u.p.nakedReturn()
-	unsetReceiver(p)
+	unsetReceiver(u.ctx, p)
+
+	if st, ok := p.(*Struct); ok {
+		for i := range st.Fields {
+			if st.Fields[i].HasTagPart("peek") {
+				u.peek(st, i)
+			}
+			if typ, ok := st.Fields[i].TagPartValue("lazy"); ok {
+				u.lazy(st, i, typ)
+			}
+		}
+	}
 	return u.p.err
 }
 
+// lazy prints Decode{{Field}} for a msgp.Raw field that
+// holds a serialized typ.
+func (u *unmarshalGen) lazy(s *Struct, i int, typ string) {
+	if !u.p.ok() {
+		return
+	}
+	sf := s.Fields[i]
+	u.p.printf("\n\n// Decode%s decodes the lazily kept %s field (index %d) into a %s.", sf.FieldName, sf.FieldName, sf.FieldTag, typ)
+	u.p.printf("\n// A nil or empty field yields the zero value.")
+	u.p.printf("\nfunc (z *%s) Decode%s() (v %s, err error) {", s.TypeName(), sf.FieldName, typ)
+	u.p.printf("\nif len(z.%[1]s) == 0 || msgp.IsNil(z.%[1]s) {\nreturn\n}", sf.FieldName)
+	u.p.printf("\n_, err = v.UnmarshalMsg(z.%s)", sf.FieldName)
+	u.p.wrapErrCheck(fmt.Sprintf("%q", sf.FieldName))
+	u.p.nakedReturn()
+}
+
+// peek prints Peek{{Type}}{{Field}}, which decodes only
+// the i-th field of a serialized struct. Fields are
+// already ordered by index and gaps are filled with
+// placeholders, so the field tag is the position inside
+// the array.
+func (u *unmarshalGen) peek(s *Struct, i int) {
+	if !u.p.ok() {
+		return
+	}
+	sf := s.Fields[i]
+	fieldElem := sf.FieldElem.Copy()
+	fieldElem.setVarname("v", u.ctx.idents)
+
+	pos := int(sf.FieldTag)
+	u.p.printf("\n\n// Peek%s%s reads field %s (index %d) from a serialized %s", s.TypeName(), sf.FieldName, sf.FieldName, pos, s.TypeName())
+	u.p.printf("\n// without unmarshaling the other fields.")
+	u.p.printf("\nfunc Peek%s%s(bts []byte) (v %s, err error) {", s.TypeName(), sf.FieldName, fieldElem.TypeName())
+	sz := u.ctx.randIdent()
+	u.p.declare(sz, u32)
+	u.assignAndCheck(sz, arrayHeader)
+	u.p.arrayCheck(strconv.Itoa(s.ArrayLen()), sz)
+	if pos > 0 {
+		idx := u.ctx.randIdent()
+		u.p.printf("\nfor %[1]s := 0; %[1]s < %[2]d; %[1]s++ {", idx, pos)
+		u.p.print("\nbts, err = msgp.Skip(bts)")
+		u.p.wrapErrCheck(u.ctx.ArgsStr())
+		u.p.closeblock()
+	}
+	u.ctx.PushString(sf.FieldName)
+	anField := sf.HasTagPart("allownil") && fieldElem.AllowNil()
+	if anField {
+		u.p.printf("\nif msgp.IsNil(bts) {\nreturn\n}")
+	}
+	SetIsAllowNil(fieldElem, anField)
+	next(u, fieldElem)
+	u.ctx.Pop()
+	u.p.print("\n_ = bts")
+	u.p.nakedReturn()
+}
+
 // does assignment to the variable "name" with the type "base"
 func (u *unmarshalGen) assignAndCheck(name string, base string) {
 	if !u.p.ok() {
@@ -73,17 +140,40 @@ func (u *unmarshalGen) gStruct(s *Struct) {
 	// }
 }
 
+// readHeader reads an array or map header into sz.
+// C# writes null collections as nil, which is read as
+// an empty collection.
+func (u *unmarshalGen) readHeader(sz string, typ string) {
+	u.p.declare(sz, u32)
+	u.p.print("\nif msgp.IsNil(bts) {\nbts, err = msgp.ReadNilBytes(bts)")
+	u.p.wrapErrCheck(u.ctx.ArgsStr())
+	u.p.print("\n} else {")
+	u.assignAndCheck(sz, typ)
+	u.p.closeblock()
+	u.p.maxLenCheck(sz, u.ctx)
+}
+
+// openNil opens a block that reads a nil in place of e
+// and leaves e zeroed. The caller closes the block.
+func (u *unmarshalGen) openNil(e Elem) {
+	u.p.print("\nif msgp.IsNil(bts) {\nbts, err = msgp.ReadNilBytes(bts)")
+	u.p.wrapErrCheck(u.ctx.ArgsStr())
+	u.p.zeroValue(e)
+	u.p.print("\n} else {")
+}
+
 func (u *unmarshalGen) tuple(s *Struct) {
 	// open block
-	sz := randIdent()
+	u.openNil(s)
+	sz := u.ctx.randIdent()
 	u.p.declare(sz, u32)
 	u.assignAndCheck(sz, arrayHeader)
-	u.p.arrayCheck(strconv.Itoa(len(s.Fields)), sz)
+	u.p.arrayCheck(strconv.Itoa(s.ArrayLen()), sz)
 	for i := range s.Fields {
 		if !u.p.ok() {
 			return
 		}
-		u.p.printf("\n// idx %d", i)
+		u.p.printf("\n// idx %d", s.Fields[i].FieldTag)
 		u.ctx.PushString(s.Fields[i].FieldName)
 		fieldElem := s.Fields[i].FieldElem
 		anField := s.Fields[i].HasTagPart("allownil") && fieldElem.AllowNil()
@@ -97,6 +187,7 @@ func (u *unmarshalGen) tuple(s *Struct) {
 			u.p.printf("\n}")
 		}
 	}
+	u.p.closeblock() // close nil check
 }
 
 // func (u *unmarshalGen) mapstruct(s *Struct) {
@@ -183,16 +274,27 @@ func (u *unmarshalGen) gBase(b *BaseElem) {
 	lowered := b.Varname() // passed as argument
 	// begin 'tmp' block
 	if b.Convert && b.Value != IDENT { // we don't need block for 'tmp' in case of IDENT
-		refname = randIdent()
+		refname = u.ctx.randIdent()
 		lowered = b.ToBase() + "(" + lowered + ")"
+		if b.ShimMode == Convert {
+			// the conversion may fail, so there is no buffer to reuse
+			lowered = "nil"
+		}
 		u.p.printf("\n{\nvar %s %s", refname, b.BaseType())
 	}
 
 	switch b.Value {
 	case Bytes:
+		u.p.printf("\nif msgp.IsNil(bts) {\nbts, err = msgp.ReadNilBytes(bts)\n%[1]s = %[1]s[:0]\n} else {", refname)
 		u.p.printf("\n%s, bts, err = msgp.ReadBytesBytes(bts, %s)", refname, lowered)
+		u.p.closeblock()
 	case Ext:
 		u.p.printf("\nbts, err = msgp.ReadExtensionBytes(bts, %s)", lowered)
+	case BigInt, BigIntPtr:
+		if b.Value == BigIntPtr {
+			u.p.printf("\nif %[1]s == nil {\n%[1]s = new(big.Int)\n}", lowered)
+		}
+		u.p.printf("\nbts, err = csmsgp.ReadBigIntBytes(bts, %s)", lowered)
 	case IDENT:
 		if b.Convert {
 			lowered = b.ToBase() + "(" + lowered + ")"
@@ -227,26 +329,26 @@ func (u *unmarshalGen) gArray(a *Array) {
 
 	// special case for [const]byte objects
 	// see decode.go for symmetry
+	u.openNil(a)
 	if be, ok := a.Els.(*BaseElem); ok && be.Value == Byte {
 		u.p.printf("\nbts, err = msgp.ReadExactBytes(bts, (%s)[:])", a.Varname())
 		u.p.wrapErrCheck(u.ctx.ArgsStr())
-		return
+	} else {
+		sz := u.ctx.randIdent()
+		u.p.declare(sz, u32)
+		u.assignAndCheck(sz, arrayHeader)
+		u.p.arrayCheck(coerceArraySize(a.Size), sz)
+		u.p.rangeBlock(u.ctx, a.Index, a.Varname(), u, a.Els)
 	}
-
-	sz := randIdent()
-	u.p.declare(sz, u32)
-	u.assignAndCheck(sz, arrayHeader)
-	u.p.arrayCheck(coerceArraySize(a.Size), sz)
-	u.p.rangeBlock(u.ctx, a.Index, a.Varname(), u, a.Els)
+	u.p.closeblock() // close nil check
 }
 
 func (u *unmarshalGen) gSlice(s *Slice) {
 	if !u.p.ok() {
 		return
 	}
-	sz := randIdent()
-	u.p.declare(sz, u32)
-	u.assignAndCheck(sz, arrayHeader)
+	sz := u.ctx.randIdent()
+	u.readHeader(sz, arrayHeader)
 	if s.isAllowNil {
 		u.p.resizeSliceNoNil(sz, s)
 	} else {
@@ -259,9 +361,12 @@ func (u *unmarshalGen) gMap(m *Map) {
 	if !u.p.ok() {
 		return
 	}
-	sz := randIdent()
-	u.p.declare(sz, u32)
-	u.assignAndCheck(sz, mapHeader)
+	if m.IsSet {
+		u.gSet(m)
+		return
+	}
+	sz := u.ctx.randIdent()
+	u.readHeader(sz, mapHeader)
 
 	// allocate or clear map
 	u.p.resizeMap(sz, m)
@@ -272,8 +377,8 @@ func (u *unmarshalGen) gMap(m *Map) {
 
 	// loop and get key,value
 	u.p.printf("\nfor %s > 0 {", sz)
-	u.p.printf("\nvar %s %s; var %s %s; %s--", m.Keyidx, m.Key.TypeName(), m.Validx, m.Value.TypeName(), sz)
-	u.assignAndCheck(m.Keyidx, strings.Title(m.Key.TypeName()))
+	u.p.printf("\nvar %s %s; var %s %s; %s--", m.Keyidx, m.KeyBaseType(), m.Validx, m.Value.TypeName(), sz)
+	u.assignAndCheck(m.Keyidx, m.KeyBaseName())
 	u.ctx.PushVar(m.Keyidx)
 	m.Value.SetIsAllowNil(false)
 	next(u, m.Value)
@@ -282,6 +387,18 @@ func (u *unmarshalGen) gMap(m *Map) {
 	u.p.closeblock()
 }
 
+// gSet reads an array of keys into a set.
+func (u *unmarshalGen) gSet(m *Map) {
+	sz := u.ctx.randIdent()
+	u.readHeader(sz, arrayHeader)
+	u.p.resizeMap(sz, m)
+	u.p.printf("\nfor %s > 0 {", sz)
+	u.p.printf("\nvar %s %s; %s--", m.Keyidx, m.KeyBaseType(), sz)
+	u.assignAndCheck(m.Keyidx, m.KeyBaseName())
+	u.p.setAssign(m)
+	u.p.closeblock()
+}
+
 func (u *unmarshalGen) gPtr(p *Ptr) {
 	//u.p.printf("\nif msgp.IsNil(bts) { bts, err = msgp.ReadNilBytes(bts); if err != nil { return }; %s = nil; } else { ", p.Varname())
 	u.p.initPtr(p)
@@ -289,8 +406,17 @@ func (u *unmarshalGen) gPtr(p *Ptr) {
 	//u.p.closeblock()
 }
 
-func (u *unmarshalGen) gNilSpaceholder() {
-	u.p.printf("\nbts, err = msgp.ReadNilBytes(bts)\n if err != nil { return }; ")
+func (u *unmarshalGen) gNilSpaceholder(n *NilPlaceholder) {
+	if !u.p.ok() {
+		return
+	}
+	// unused indexes are skipped, whatever they hold
+	if n.Len() == 1 {
+		u.p.print("\nbts, err = msgp.Skip(bts)")
+	} else {
+		u.p.printf("\nfor %[1]s := 0; %[1]s < %[2]d && err == nil; %[1]s++ {\nbts, err = msgp.Skip(bts)\n}", u.ctx.randIdent(), n.Len())
+	}
+	u.p.print("\nif err != nil {\nreturn\n}") // unused indexes have no name
 }
 
 func (u *unmarshalGen) gCsharpString(s *CsharpString) {
@@ -298,5 +424,13 @@ func (u *unmarshalGen) gCsharpString(s *CsharpString) {
 		return
 	}
 	// if dc.isnill readnil else readstring
-	u.p.printf("\n"+`if msgp.IsNil(bts) { bts, err = msgp.ReadNilBytes(bts); if err != nil { return }; %s = ""; } else { %s, bts, err = msgp.ReadStringBytes(bts) }`, s.Varname(), s.Varname())
+	u.p.printf("\nif msgp.IsNil(bts) {\nbts, err = msgp.ReadNilBytes(bts)\n%s = \"\"\n} else {", s.Varname())
+	if s.Named() {
+		tmp := u.ctx.randIdent()
+		u.p.printf("\nvar %[1]s string\n%[1]s, bts, err = msgp.ReadStringBytes(bts)\n%[2]s = %[3]s(%[1]s)", tmp, s.Varname(), s.TypeName())
+	} else {
+		u.p.printf("\n%s, bts, err = msgp.ReadStringBytes(bts)", s.Varname())
+	}
+	u.p.closeblock()
+	u.p.wrapErrCheck(u.ctx.ArgsStr())
 }
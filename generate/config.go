@@ -0,0 +1,179 @@
+package generate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/aggronmagi/csmsgp2go/parse"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigNames are the names of the configuration
+// files found by FindConfig, in order of preference.
+var ConfigNames = []string{"csmsgp2go.yaml", "csmsgp2go.yml", "csmsgp2go.toml"}
+
+// A Config holds project-wide defaults, read from a
+// csmsgp2go.yaml or csmsgp2go.toml file:
+//
+//	io: true
+//	tests: false
+//	newtime: true
+//	tag: json
+//	output: _msgp.go
+//	shims:
+//	  - type: net.IP
+//	    as: string
+//	    using: ipString/parseIP
+//	replaces:
+//	  - type: Money
+//	    with: int64
+//
+// Command line flags override IO, Marshal, Tests and
+// Unexported. The other settings are applied as directives
+// at the top of each file, so the file's own directives
+// override them; a file turns off a switch like newtime
+// with //msgp:newtime false.
+type Config struct {
+	IO         *bool `yaml:"io" toml:"io"`                 // -io
+	Marshal    *bool `yaml:"marshal" toml:"marshal"`       // -marshal
+	Tests      *bool `yaml:"tests" toml:"tests"`           // -tests
+	Unexported *bool `yaml:"unexported" toml:"unexported"` // -unexported
+
+	CompactFloats bool   `yaml:"compactfloats" toml:"compactfloats"` // //msgp:compactfloats
+	ClearOmitted  bool   `yaml:"clearomitted" toml:"clearomitted"`   // //msgp:clearomitted
+	NewTime       bool   `yaml:"newtime" toml:"newtime"`             // //msgp:newtime
+	Deterministic bool   `yaml:"deterministic" toml:"deterministic"` // //msgp:deterministic
+	Tag           string `yaml:"tag" toml:"tag"`                     // //msgp:tag
+
+	// Output replaces the _gen.go suffix of generated files.
+	Output string `yaml:"output" toml:"output"`
+
+	Shims    []ConfigShim    `yaml:"shims" toml:"shims"`       // //msgp:shim
+	Replaces []ConfigReplace `yaml:"replaces" toml:"replaces"` // //msgp:replace
+
+	// Path is the file the Config was read from.
+	Path string `yaml:"-" toml:"-"`
+}
+
+// ConfigShim is a //msgp:shim {Type} as:{As} using:{Using} mode:{Mode}.
+type ConfigShim struct {
+	Type  string `yaml:"type" toml:"type"`
+	As    string `yaml:"as" toml:"as"`
+	Using string `yaml:"using" toml:"using"`
+	Mode  string `yaml:"mode" toml:"mode"` // optional
+}
+
+// ConfigReplace is a //msgp:replace {Type} with:{With}.
+type ConfigReplace struct {
+	Type string `yaml:"type" toml:"type"`
+	With string `yaml:"with" toml:"with"`
+}
+
+// FindConfig returns the configuration file in dir or the
+// closest of its parents, or nil if there is none.
+func FindConfig(dir string) (*Config, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		for _, name := range ConfigNames {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				return LoadConfig(path)
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// LoadConfig reads the configuration file at path. Files
+// ending in .toml are TOML, and others YAML. Unknown keys
+// are errors.
+func LoadConfig(path string) (*Config, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{Path: path}
+	if strings.HasSuffix(path, ".toml") {
+		md, err := toml.Decode(string(src), c)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if keys := md.Undecoded(); len(keys) > 0 {
+			return nil, fmt.Errorf("%s: unknown key %q", path, keys[0].String())
+		}
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(src))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return c, nil
+}
+
+// Directives returns the directives set by c, positioned at
+// its file. They go before the directives of each file.
+func (c *Config) Directives() []parse.Directive {
+	if c == nil {
+		return nil
+	}
+	var args [][]string
+	for _, d := range []struct {
+		set  bool
+		name string
+	}{
+		{c.CompactFloats, "compactfloats"},
+		{c.ClearOmitted, "clearomitted"},
+		{c.NewTime, "newtime"},
+		{c.Deterministic, "deterministic"},
+	} {
+		if d.set {
+			args = append(args, []string{d.name})
+		}
+	}
+	if c.Tag != "" {
+		args = append(args, []string{"tag", c.Tag})
+	}
+	for _, s := range c.Shims {
+		a := []string{"shim", s.Type, "as:" + s.As, "using:" + s.Using}
+		if s.Mode != "" {
+			a = append(a, "mode:"+s.Mode)
+		}
+		args = append(args, a)
+	}
+	for _, r := range c.Replaces {
+		args = append(args, []string{"replace", r.Type, "with:" + r.With})
+	}
+	dirs := make([]parse.Directive, len(args))
+	for i, a := range args {
+		dirs[i] = parse.Directive{Args: a, Pos: token.Position{Filename: c.Path}}
+	}
+	return dirs
+}
+
+// OutputFile is like the OutputFile function, but uses
+// the Output suffix of c, if set.
+func (c *Config) OutputFile(file, pkg string) string {
+	name := OutputFile(file, pkg)
+	if c == nil || c.Output == "" {
+		return name
+	}
+	return strings.TrimSuffix(name, "_gen.go") + c.Output
+}
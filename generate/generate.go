@@ -0,0 +1,153 @@
+// Package generate runs csmsgp2go in process: it parses
+// Go types from files or from memory and returns the
+// formatted methods, without writing anything to disk.
+//
+//	code, tests, err := generate.Generate(generate.Options{
+//		File:   "schema.go",
+//		Source: src,
+//		Mode:   gen.Marshal | gen.Unmarshal | gen.Size | gen.Test,
+//	})
+//
+// Write writes them to disk, like the csmsgp2go command.
+package generate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aggronmagi/csmsgp2go/gen"
+	"github.com/aggronmagi/csmsgp2go/parse"
+	"github.com/aggronmagi/csmsgp2go/printer"
+)
+
+// Options are the inputs of Generate. They match
+// the flags of the csmsgp2go command.
+type Options struct {
+	// File is the Go file, or directory of a package,
+	// to generate methods for. With Source, it only
+	// names the source in errors and locates the
+	// packages it imports.
+	File string
+
+	// Source, if not nil, is the content of File,
+	// which is then not read.
+	Source []byte
+
+	// Mode is the methods to generate. Modes with
+	// dependencies are not expanded; for example,
+	// gen.Diff needs gen.Marshal and gen.Unmarshal.
+	Mode gen.Method
+
+	Unexported bool   // also process unexported types
+	Strict     bool   // report warnings as errors
+	Lint       string // "csharp" reports fields without a C# round-trip as errors
+
+	// Tags are the build tags satisfied when File is a directory.
+	Tags []string
+
+	// Output is the name of the generated file; goimports
+	// resolves imports from its directory. If empty, it is
+	// Config.OutputFile of File and its package.
+	Output string
+
+	// Config, if not nil, supplies directives shared across
+	// packages, which the directives of File override. Its
+	// flag defaults are not used; set Mode and Unexported.
+	Config *Config
+
+	// Logf, if not nil, logs what is inferred from
+	// the source, and warnings.
+	Logf func(s string, v ...interface{})
+}
+
+// Generate returns the formatted methods for the types in
+// opts.File and, if opts.Mode has gen.Test, their tests.
+// Errors in the source are returned as a parse.ErrorList.
+// Generate is safe to call from multiple goroutines.
+func Generate(opts Options) (code, tests []byte, err error) {
+	_, code, tests, err = run(opts)
+	return code, tests, err
+}
+
+// Write is like Generate, but writes the methods to the output
+// file and their tests next to it, and returns the name of the
+// output file. If the code can't be formatted, it is written
+// to the output file name with a .broken suffix instead.
+func Write(opts Options) (string, error) {
+	if opts.Source != nil {
+		return "", errors.New("can't write output for Source")
+	}
+	file, code, tests, err := run(opts)
+	if err != nil {
+		if code != nil {
+			broken := file + ".broken"
+			os.WriteFile(broken, code, 0o600)
+			return "", fmt.Errorf("%w; wrote broken output to %s", err, broken)
+		}
+		return "", err
+	}
+	if err = os.WriteFile(file, code, 0o600); err != nil {
+		return "", err
+	}
+	if tests != nil {
+		if err = os.WriteFile(printer.TestFile(file), tests, 0o600); err != nil {
+			return "", err
+		}
+	}
+	return file, nil
+}
+
+// run returns the name of the output file with the
+// results of Generate.
+func run(opts Options) (file string, code, tests []byte, err error) {
+	if opts.File == "" {
+		return "", nil, nil, errors.New("no file to parse")
+	}
+	if opts.Mode&^gen.Test == 0 {
+		return "", nil, nil, errors.New("no methods to generate")
+	}
+	if opts.Lint != "" && opts.Lint != "csharp" {
+		return "", nil, nil, fmt.Errorf("unknown lint %q; expected csharp", opts.Lint)
+	}
+
+	popts := parse.Options{
+		Unexported: opts.Unexported,
+		Strict:     opts.Strict,
+		Tags:       opts.Tags,
+		Logf:       opts.Logf,
+		Directives: opts.Config.Directives(),
+	}
+	var fs *parse.FileSet
+	if opts.Source != nil {
+		fs, err = parse.Source(opts.File, opts.Source, popts)
+	} else {
+		fs, err = parse.File(opts.File, popts)
+	}
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if opts.Lint == "csharp" {
+		if err = fs.LintCsharp(); err != nil {
+			return "", nil, nil, err
+		}
+	}
+	file = opts.Output
+	if file == "" {
+		file = opts.Config.OutputFile(opts.File, fs.Package)
+	}
+	code, tests, err = printer.Generate(file, fs, opts.Mode)
+	return file, code, tests, err
+}
+
+// OutputFile returns the default name of the file generated
+// for file, a Go file or a directory of package pkg.
+func OutputFile(file, pkg string) string {
+	if fi, err := os.Stat(file); err == nil && fi.IsDir() {
+		file = filepath.Join(file, pkg)
+	}
+	// new file name is old file name + _gen.go
+	return strings.TrimSuffix(file, ".go") + "_gen.go"
+}
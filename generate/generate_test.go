@@ -0,0 +1,383 @@
+package generate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aggronmagi/csmsgp2go/gen"
+	"github.com/aggronmagi/csmsgp2go/parse"
+)
+
+const schema = `package schema
+
+type Player struct {
+	Name  string  ` + "`msg:\"0\"`" + `
+	Level int32   ` + "`msg:\"1\"`" + `
+	Items []int64 ` + "`msg:\"2\"`" + `
+}
+`
+
+func TestGenerateSource(t *testing.T) {
+	dir := t.TempDir()
+	code, tests, err := Generate(Options{
+		File:   filepath.Join(dir, "schema.go"),
+		Source: []byte(schema),
+		Mode:   gen.Marshal | gen.Unmarshal | gen.Size | gen.Test,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"package schema\n",
+		"func (z *Player) MarshalMsg(b []byte) (o []byte, err error) {",
+		"func (z *Player) UnmarshalMsg(bts []byte) (o []byte, err error) {",
+	} {
+		if !bytes.Contains(code, []byte(want)) {
+			t.Errorf("code has no %q", want)
+		}
+	}
+	if !bytes.Contains(tests, []byte("func TestMarshalUnmarshalPlayer(t *testing.T) {")) {
+		t.Errorf("tests has no TestMarshalUnmarshalPlayer:\n%s", tests)
+	}
+	if ents, _ := os.ReadDir(dir); len(ents) != 0 {
+		t.Errorf("Generate wrote %d files", len(ents))
+	}
+
+	// without Test
+	_, tests, err = Generate(Options{
+		File:   filepath.Join(dir, "schema.go"),
+		Source: []byte(schema),
+		Mode:   gen.Encode | gen.Decode | gen.Size,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tests != nil {
+		t.Error("tests generated without gen.Test")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "schema.go")
+	if err := os.WriteFile(file, []byte(schema), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{File: file, Mode: gen.Marshal | gen.Unmarshal | gen.Size | gen.Test}
+	code, tests, err := Generate(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, output := range []string{"", filepath.Join(dir, "out.go")} {
+		opts.Output = output
+		out, err := Write(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := filepath.Join(dir, "schema_gen.go"); output == "" && out != want {
+			t.Errorf("wrote %s; want %s", out, want)
+		} else if output != "" && out != output {
+			t.Errorf("wrote %s; want %s", out, output)
+		}
+		if got, _ := os.ReadFile(out); !bytes.Equal(got, code) {
+			t.Errorf("%s differs from Generate", out)
+		}
+		if got, _ := os.ReadFile(strings.TrimSuffix(out, ".go") + "_test.go"); !bytes.Equal(got, tests) {
+			t.Errorf("tests of %s differ from Generate", out)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	src := []byte("package schema\n\ntype A struct {\n\tP *int `msg:\"0\"`\n\tC uint `msg:\"1\"`\n}\n")
+	opts := Options{File: "schema.go", Source: src, Mode: gen.Marshal | gen.Unmarshal}
+	_, _, err := Generate(opts)
+	var el parse.ErrorList
+	if !errors.As(err, &el) || len(el) != 1 || el[0].Pos.Line != 4 {
+		t.Fatalf("error = %v; want one error on line 4", err)
+	}
+
+	opts.Source = bytes.Replace(src, []byte("\tP *int `msg:\"0\"`\n"), nil, 1)
+	if _, _, err = Generate(opts); err != nil {
+		t.Fatal(err)
+	}
+	opts.Lint = "csharp"
+	_, _, err = Generate(opts)
+	if !errors.As(err, &el) || len(el) != 1 || el[0].Pos.Line != 4 {
+		t.Fatalf("lint error = %v; want one error on line 4", err)
+	}
+
+	opts.Mode = gen.Test
+	if _, _, err = Generate(opts); err == nil {
+		t.Error("no error without methods")
+	}
+
+	// the index after 65535 doesn't wrap to 0
+	opts = Options{
+		File:   "schema.go",
+		Source: []byte("package schema\n\ntype A struct {\n\tX int32 `msg:\"0\"`\n\tY int32 `msg:\"65535\"`\n\tZ int32\n}\n"),
+		Mode:   gen.Marshal | gen.Unmarshal,
+	}
+	_, _, err = Generate(opts)
+	if !errors.As(err, &el) || len(el) != 1 || el[0].Pos.Line != 6 || !strings.Contains(el[0].Msg, "out of range") {
+		t.Fatalf("index overflow error = %v; want one error on line 6", err)
+	}
+
+	// a bad map key is one positioned error, not also a warning
+	var logged []string
+	opts = Options{
+		File:   "schema.go",
+		Source: []byte("package schema\n\ntype K float64\n\ntype A struct {\n\tM map[K]int `msg:\"0\"`\n}\n"),
+		Mode:   gen.Marshal | gen.Unmarshal,
+		Logf:   func(format string, args ...interface{}) { logged = append(logged, fmt.Sprintf(format, args...)) },
+	}
+	_, _, err = Generate(opts)
+	if !errors.As(err, &el) || len(el) != 1 || !strings.Contains(el[0].Msg, "map key") {
+		t.Fatalf("map key error = %v; want one error", err)
+	}
+	for _, l := range logged {
+		if strings.Contains(l, "map key") {
+			t.Errorf("map key error also logged: %q", l)
+		}
+	}
+}
+
+// Unity types are found by import path, not by package name.
+func TestGenerateUnityImports(t *testing.T) {
+	for _, tc := range []struct {
+		imp  string
+		want bool // the field type is a builtin
+	}{
+		{`"github.com/aggronmagi/csmsgp2go/unity"`, true},
+		{`u "github.com/aggronmagi/csmsgp2go/unity"`, true},
+		{`"example.com/game/unity"`, false},
+	} {
+		typ := "unity.Vector3"
+		if strings.HasPrefix(tc.imp, "u ") {
+			typ = "u.Vector3"
+		}
+		src := "package schema\n\nimport " + tc.imp + "\n\ntype A struct {\n\tP " + typ + " `msg:\"0\"`\n}\n"
+		opts := Options{File: "schema.go", Source: []byte(src), Mode: gen.Marshal | gen.Unmarshal, Strict: true}
+		_, _, err := Generate(opts)
+		if builtin := err == nil; builtin != tc.want {
+			t.Errorf("import %s: error = %v; want builtin %v", tc.imp, err, tc.want)
+		}
+	}
+}
+
+func TestGenerateConcurrent(t *testing.T) {
+	srcs := [][]byte{
+		[]byte(schema),
+		[]byte("package schema\n\n//msgp:maxlen 64\n\ntype Grid struct {\n\tCells [][]map[string][]int32 `msg:\"0\"`\n\tTags  map[int32]string       `msg:\"3\"`\n}\n"),
+		[]byte("package schema\n\n//msgp:deterministic\n\ntype Index map[string][]float64\n\ntype Pair [2]map[string]bool\n"),
+	}
+	dir := t.TempDir()
+	mode := gen.Encode | gen.Decode | gen.Marshal | gen.Unmarshal | gen.Size | gen.Test
+	want := make([][]byte, len(srcs))
+	for i, src := range srcs {
+		code, _, err := Generate(Options{File: filepath.Join(dir, "schema.go"), Source: src, Mode: mode})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want[i] = code
+	}
+
+	const runs = 8
+	errs := make(chan error, runs*len(srcs))
+	for r := 0; r < runs; r++ {
+		for i, src := range srcs {
+			go func(i int, src []byte) {
+				code, _, err := Generate(Options{File: filepath.Join(dir, "schema.go"), Source: src, Mode: mode})
+				if err == nil && !bytes.Equal(code, want[i]) {
+					err = fmt.Errorf("source %d: concurrent output differs:\n%s", i, code)
+				}
+				errs <- err
+			}(i, src)
+		}
+	}
+	for n := 0; n < runs*len(srcs); n++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestPackages(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"a/a.go":          "package a\n\n//msgp:generate\n\ntype A struct{ X int32 }\n",
+		"b/b.go":          "package b\n\ntype B struct {\n\tX int32 `msg:\"0\"`\n}\n",
+		"b/c/c.go":        "package c\n\ntype C struct{ X int32 }\n",
+		"b/c/c_test.go":   "package c\n\ntype T struct {\n\tX int32 `msg:\"0\"`\n}\n",
+		"d/d_gen.go":      "package d\n\n//msgp:generate\n",
+		"testdata/t/t.go": "package t\n\n//msgp:generate\n",
+		"_x/x.go":         "package x\n\n//msgp:generate\n",
+		"m/go.mod":        "module m\n",
+		"m/m.go":          "package m\n\n//msgp:generate\n",
+		"e/e.go":          "//go:build extra\n\npackage e\n\n//msgp:generate\n",
+		"f/f.go":          "package f\n\n//msgp:generate\n\nfunc f() {\n",
+		"g/g.go":          "package g\n\nfunc g() {\n",
+		"h/h1.go":         "package h1\n\n//msgp:generate\n",
+		"h/h2.go":         "package h2\n",
+	}
+	for name, src := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !IsPattern(root+"/...") || !IsPattern("./...") || IsPattern(root) {
+		t.Error("IsPattern")
+	}
+	// f has a marker before its syntax error, which is left to
+	// the generator; g has none. h has two packages, so it is
+	// reported without stopping the walk.
+	dirs, err := Packages(root+"/...", nil)
+	if err == nil || !strings.Contains(err.Error(), filepath.Join(root, "h")+":") {
+		t.Errorf("error = %v; want one for h", err)
+	}
+	want := []string{filepath.Join(root, "a"), filepath.Join(root, "b"), filepath.Join(root, "f")}
+	if fmt.Sprint(dirs) != fmt.Sprint(want) {
+		t.Errorf("Packages = %v; want %v", dirs, want)
+	}
+
+	dirs, _ = Packages(root+"/...", []string{"extra"})
+	want = []string{filepath.Join(root, "a"), filepath.Join(root, "b"), filepath.Join(root, "e"), filepath.Join(root, "f")}
+	if fmt.Sprint(dirs) != fmt.Sprint(want) {
+		t.Errorf("Packages with tag extra = %v; want %v", dirs, want)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "pkg", "sub")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	yml := "tests: false\nnewtime: true\ntag: json\noutput: _msgp.go\nreplaces:\n  - type: Money\n    with: int64\n"
+	if err := os.WriteFile(filepath.Join(root, "csmsgp2go.yaml"), []byte(yml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := FindConfig(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg == nil || cfg.Tests == nil || *cfg.Tests || cfg.IO != nil || !cfg.NewTime || cfg.Tag != "json" {
+		t.Fatalf("FindConfig = %+v", cfg)
+	}
+	if got, want := cfg.OutputFile(filepath.Join(sub, "a.go"), "sub"), filepath.Join(sub, "a_msgp.go"); got != want {
+		t.Errorf("OutputFile = %q; want %q", got, want)
+	}
+
+	// the file's //msgp:tag overrides the config's
+	src := "package sub\n\ntype Money int\n\ntype A struct {\n\tM Money `json:\"0\"`\n\tN Money `json:\"2\"`\n}\n"
+	code, _, err := Generate(Options{File: filepath.Join(sub, "a.go"), Source: []byte(src), Mode: gen.Marshal, Config: cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(code, []byte("msgp.AppendInt64(o, int64(z.M))")) || !bytes.Contains(code, []byte("0x93")) {
+		t.Errorf("config not applied:\n%s", code)
+	}
+	src = strings.Replace(src, "package sub\n", "package sub\n\n//msgp:tag msg\n", 1)
+	src = strings.NewReplacer("json", "msg", `"2"`, `"3"`).Replace(src)
+	code, _, err = Generate(Options{File: filepath.Join(sub, "a.go"), Source: []byte(src), Mode: gen.Marshal, Config: cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(code, []byte("0x94")) {
+		t.Errorf("file directive doesn't override config:\n%s", code)
+	}
+
+	// a file can turn off a switch set by the config
+	src = "package sub\n\nimport \"time\"\n\ntype T struct {\n\tAt time.Time `json:\"0\"`\n}\n"
+	code, _, err = Generate(Options{File: filepath.Join(sub, "t.go"), Source: []byte(src), Mode: gen.Marshal, Config: cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	src = strings.Replace(src, "package sub\n", "package sub\n\n//msgp:newtime false\n", 1)
+	off, _, err := Generate(Options{File: filepath.Join(sub, "t.go"), Source: []byte(src), Mode: gen.Marshal, Config: cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(code, []byte("AppendTimeExt")) || bytes.Contains(off, []byte("AppendTimeExt")) {
+		t.Errorf("//msgp:newtime false doesn't override config:\n%s", off)
+	}
+
+	// TOML, closer to the package
+	if err := os.WriteFile(filepath.Join(sub, "csmsgp2go.toml"), []byte("io = true\n[[shims]]\ntype = \"Money\"\nas = \"int64\"\nusing = \"a/b\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = FindConfig(sub); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.IO == nil || !*cfg.IO || len(cfg.Shims) != 1 || cfg.Shims[0].Using != "a/b" || cfg.Tag != "" {
+		t.Errorf("FindConfig = %+v", cfg)
+	}
+	// a shared shim gets no methods where the type isn't declared
+	src = "package sub\n\ntype B struct {\n\tX int32 `msg:\"0\"`\n}\n"
+	code, _, err = Generate(Options{File: filepath.Join(sub, "b.go"), Source: []byte(src), Mode: gen.Marshal, Config: cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(code, []byte("Money")) {
+		t.Errorf("methods generated for Money:\n%s", code)
+	}
+
+	for name, bad := range map[string]string{"csmsgp2go.toml": "colour = 1\n", "csmsgp2go.yaml": "colour: 1\n"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := os.WriteFile(path, []byte(bad), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadConfig(path); err == nil {
+			t.Errorf("%s: no error for unknown key", name)
+		}
+	}
+}
+
+func TestGenerateDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.go":        "package a\n\ntype A struct {\n\tX int32 `msg:\"0\"`\n}\n",
+		"b_on.go":     "//go:build special\n\npackage a\n\ntype B struct {\n\tOn bool `msg:\"0\"`\n}\n",
+		"b_off.go":    "//go:build !special\n\npackage a\n\ntype B struct {\n\tOff bool `msg:\"0\"`\n}\n",
+		"a_test.go":   "package a_test\n\ntype T struct{}\n",
+		"a_gen.go":    "package a\n\ntype A struct{}\n",
+		"old_msgp.go": "package a\n\n// Code generated by github.com/aggronmagi/csmsgp2go DO NOT EDIT.\n\ntype B struct{}\n",
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		tags  []string
+		field string
+	}{
+		{nil, "z.Off"},
+		{[]string{"special"}, "z.On"},
+	} {
+		code, _, err := Generate(Options{File: dir, Mode: gen.Marshal, Tags: tc.tags})
+		if err != nil {
+			t.Fatalf("tags %v: %v", tc.tags, err)
+		}
+		for _, want := range []string{"func (z A) MarshalMsg", "msgp.AppendBool(o, " + tc.field + ")"} {
+			if !bytes.Contains(code, []byte(want)) {
+				t.Errorf("tags %v: code has no %q:\n%s", tc.tags, want, code)
+			}
+		}
+		if bytes.Contains(code, []byte("func (z T)")) {
+			t.Errorf("tags %v: code has methods for the test package", tc.tags)
+		}
+	}
+}
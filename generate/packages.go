@@ -0,0 +1,127 @@
+package generate
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// IsPattern reports whether file is a package pattern
+// like ./... rather than a file or directory.
+func IsPattern(file string) bool {
+	return file == "..." || strings.HasSuffix(file, "/...") ||
+		strings.HasSuffix(file, string(filepath.Separator)+"...")
+}
+
+// Packages returns the directories of the packages matched by
+// pattern, dir/..., that need methods generated: those with
+// a //msgp:generate directive or a struct field with a msg tag.
+// Like the go command, it skips testdata and vendor directories,
+// directories starting with . or _, and nested modules, and
+// selects the files of each package by their build constraints,
+// with tags satisfied. Test files and generated files are not read.
+//
+// A directory that can't be read is not returned. Its error is
+// joined into the returned error, and the walk goes on, so the
+// directories found are returned with it.
+func Packages(pattern string, tags []string) ([]string, error) {
+	root := filepath.Clean(strings.TrimSuffix(pattern, "..."))
+	bctx := build.Default
+	bctx.BuildTags = tags
+	var dirs []string
+	var errs []error
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			errs = append(errs, err)
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root {
+			name := d.Name()
+			if name == "testdata" || name == "vendor" ||
+				strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+				return filepath.SkipDir
+			}
+		}
+		ok, err := hasMarker(&bctx, path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		} else if ok {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(dirs)
+	return dirs, errors.Join(errs...)
+}
+
+// hasMarker reports whether a Go file of the package in dir,
+// chosen like parse.File does, has a //msgp:generate directive
+// or a msg struct tag. Files with syntax errors are checked as
+// far as they parse: the errors are reported when the package
+// is generated, and only if it has a marker.
+func hasMarker(bctx *build.Context, dir string) (bool, error) {
+	pkg, err := bctx.ImportDir(dir, 0)
+	var noGo *build.NoGoError
+	if errors.As(err, &noGo) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	fset := token.NewFileSet()
+	for _, name := range append(pkg.GoFiles, pkg.CgoFiles...) {
+		if strings.HasSuffix(name, "_gen.go") {
+			continue
+		}
+		f, _ := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if f != nil && fileHasMarker(f) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func fileHasMarker(f *ast.File) bool {
+	for _, cg := range f.Comments {
+		for _, c := range cg.List {
+			if c.Text == "//msgp:generate" || strings.HasPrefix(c.Text, "//msgp:generate ") {
+				return true
+			}
+		}
+	}
+	found := false
+	ast.Inspect(f, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+		if fl, ok := n.(*ast.Field); ok && fl.Tag != nil {
+			tag, err := strconv.Unquote(fl.Tag.Value)
+			if err == nil {
+				_, found = reflect.StructTag(tag).Lookup("msg")
+			}
+		}
+		return true
+	})
+	return found
+}
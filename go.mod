@@ -3,8 +3,10 @@ module github.com/aggronmagi/csmsgp2go
 go 1.20
 
 require (
+	github.com/BurntSushi/toml v1.4.0
 	github.com/tinylib/msgp v1.2.5
 	golang.org/x/tools v0.22.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -13,6 +13,7 @@ import (
 	"text/template"
 
 	"github.com/aggronmagi/csmsgp2go/gen"
+	"github.com/aggronmagi/csmsgp2go/generate"
 )
 
 // When stuff's going wrong, you'll be glad this is here!
@@ -142,7 +143,7 @@ func loadVars(t *testing.T, tpl *template.Template, tplData interface{}) (vars e
 		fmt.Println(tempDir)
 	}
 	tfile := filepath.Join(tempDir, "msg.go")
-	genFile := newFilename(tfile, "")
+	genFile := newFilename(tfile, "", nil)
 
 	if err = goGenerateTpl(tempDir, tfile, tpl, tplData); err != nil {
 		err = fmt.Errorf("could not generate code: %v", err)
@@ -231,7 +232,7 @@ func goGenerateTpl(cwd, tfile string, tpl *template.Template, tplData interface{
 
 	mode := gen.Encode | gen.Decode | gen.Size | gen.Marshal | gen.Unmarshal
 
-	return Run(tfile, mode, false)
+	return Run(generate.Options{File: tfile, Mode: mode})
 }
 
 var issue185IdentsTpl = template.Must(template.New("").Parse(`
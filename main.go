@@ -13,23 +13,39 @@
 // following options are supported, if you need them:
 //
 //	-o = output file name (default is {input}_gen.go)
-//	-file = input file name (or directory; default is $GOFILE, which is set by the `go generate` command)
+//	-file = input file name (or directory; default is $GOFILE, which is set by the `go generate` command);
+//	        dir/... generates every package under dir with a //msgp:generate directive or msg tags
 //	-io = satisfy the `msgp.Decodable` and `msgp.Encodable` interfaces (default is true)
 //	-marshal = satisfy the `msgp.Marshaler` and `msgp.Unmarshaler` interfaces (default is true)
 //	-tests = generate tests and benchmarks (default is true)
+//	-exactsize = generate ExactMsgsize methods (default is false)
+//	-stream = generate Decode{Type}Each/Encode{Type}Each for slice types (default is false; implies -io)
+//	-diff = generate Diff/ApplyPatch methods for struct types (default is false; implies -marshal)
+//	-lint csharp = report fields that don't round-trip with MessagePack-CSharp, as errors
+//	-tags = comma-separated build tags satisfied when parsing a directory
+//	-strict = report warnings, like non-local identifiers and ignored fields, as errors (default is false)
+//
+// Defaults for -io, -marshal, -tests and -unexported, and for file-level
+// directives, may be set in a csmsgp2go.yaml or csmsgp2go.toml file in the
+// directory of the input or one of its parents; see generate.Config.
+//
+// To generate in process, without writing files, see package generate.
 //
 // For more information, please read README.md, and the wiki at github.com/aggronmagi/csmsgp2go
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/aggronmagi/csmsgp2go/gen"
-	"github.com/aggronmagi/csmsgp2go/parse"
+	"github.com/aggronmagi/csmsgp2go/generate"
 	"github.com/aggronmagi/csmsgp2go/printer"
 )
 
@@ -39,7 +55,13 @@ var (
 	encode     = flag.Bool("io", false, "create Encode and Decode methods")
 	marshal    = flag.Bool("marshal", true, "create Marshal and Unmarshal methods")
 	tests      = flag.Bool("tests", true, "create tests and benchmarks")
+	exactsize  = flag.Bool("exactsize", false, "create ExactMsgsize methods")
+	streaming  = flag.Bool("stream", false, "create streaming Decode/Encode{Type}Each funcs for slice types (implies -io)")
+	diff       = flag.Bool("diff", false, "create Diff and ApplyPatch methods for struct types (implies -marshal)")
 	unexported = flag.Bool("unexported", false, "also process unexported types")
+	lint       = flag.String("lint", "", "report fields that don't round-trip with the given language: csharp")
+	strict     = flag.Bool("strict", false, "report warnings, like non-local identifiers and ignored fields, as errors")
+	buildTags  = flag.String("tags", "", "comma-separated build tags satisfied when parsing a directory")
 	verbose    = flag.Bool("v", false, "verbose diagnostics")
 )
 
@@ -61,11 +83,6 @@ func exitln(res string) {
 func main() {
 	flag.Parse()
 
-	if *verbose {
-		printer.Logf = diagf
-		parse.Logf = diagf
-	}
-
 	// GOFILE is set by go generate
 	if *file == "" {
 		*file = os.Getenv("GOFILE")
@@ -74,48 +91,187 @@ func main() {
 		}
 	}
 
+	if *lint != "" && *lint != "csharp" {
+		exitln(fmt.Sprintf("Unknown -lint %q; expected csharp", *lint))
+	}
+
+	flag.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+
+	if generate.IsPattern(*file) {
+		if *out != "" {
+			exitln("-o can't be used with " + *file)
+		}
+		if err := RunAll(*file); err != nil {
+			exitln(err.Error())
+		}
+		return
+	}
+
+	cfg, err := findConfig(*file)
+	if err != nil {
+		exitln(err.Error())
+	}
+	opts := options(*file, cfg)
+	if opts.Mode&^gen.Test == 0 {
+		exitln("No methods to generate; -io=false && -marshal=false")
+	}
+	if err := Run(opts); err != nil {
+		exitln(err.Error())
+	}
+}
+
+// setFlags holds the names of the flags set on the command line.
+var setFlags = map[string]bool{}
+
+// options returns the options for generating file from the flags
+// and cfg, which may be nil. Flags set on the command line override
+// cfg, which overrides the flag defaults.
+func options(file string, cfg *generate.Config) generate.Options {
+	flagOr := func(name string, v *bool, c *bool) bool {
+		if !setFlags[name] && c != nil {
+			return *c
+		}
+		return *v
+	}
+	opts := generate.Options{
+		File:   file,
+		Strict: *strict,
+		Lint:   *lint,
+		Tags:   splitTags(*buildTags),
+		Config: cfg,
+		Logf:   diagf,
+	}
+	if *out != "" {
+		opts.Output = newFilename(file, "", cfg)
+	}
+	if cfg == nil {
+		cfg = &generate.Config{}
+	}
+
 	var mode gen.Method
-	if *encode {
+	if flagOr("io", encode, cfg.IO) {
 		mode |= (gen.Encode | gen.Decode | gen.Size)
 	}
-	if *marshal {
+	if flagOr("marshal", marshal, cfg.Marshal) {
 		mode |= (gen.Marshal | gen.Unmarshal | gen.Size)
 	}
-	if *tests {
+	if *streaming {
+		mode |= (gen.Stream | gen.Encode | gen.Decode | gen.Size)
+	}
+	if *diff {
+		mode |= (gen.Diff | gen.Marshal | gen.Unmarshal | gen.Size)
+	}
+	if *exactsize {
+		mode |= gen.ExactSize
+	}
+	if flagOr("tests", tests, cfg.Tests) {
 		mode |= gen.Test
 	}
+	opts.Mode = mode
+	opts.Unexported = flagOr("unexported", unexported, cfg.Unexported)
+	return opts
+}
 
-	if mode&^gen.Test == 0 {
-		exitln("No methods to generate; -io=false && -marshal=false")
+// RunAll runs Run in parallel for each package matched by
+// pattern, dir/..., writing one file per package with the
+// options of its configuration file. It reports each failure,
+// including directories that couldn't be read, and a summary,
+// and fails if any package fails.
+func RunAll(pattern string) error {
+	dirs, scanErr := generate.Packages(pattern, splitTags(*buildTags))
+	if len(dirs) == 0 {
+		if scanErr != nil {
+			return scanErr
+		}
+		return fmt.Errorf("no packages to generate in %s", pattern)
+	}
+	if scanErr != nil {
+		fmt.Fprintln(os.Stderr, scanErr)
 	}
 
-	if err := Run(*file, mode, *unexported); err != nil {
-		exitln(err.Error())
+	errs := make([]error, len(dirs))
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	var wg sync.WaitGroup
+	for i, dir := range dirs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, dir string) {
+			defer wg.Done()
+			errs[i] = runPackage(dir)
+			<-sem
+		}(i, dir)
+	}
+	wg.Wait()
+
+	failed := 0
+	for i, err := range errs {
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "%s:\n%s\n", dirs[i], err)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d packages: %d generated, %d failed\n", len(dirs), len(dirs)-failed, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d packages failed", failed, len(dirs))
+	}
+	if scanErr != nil {
+		return errors.New("some directories could not be read")
 	}
+	return nil
 }
 
-// Run writes all methods using the associated file or path, e.g.
+func runPackage(dir string) error {
+	cfg, err := generate.FindConfig(dir)
+	if err != nil {
+		return err
+	}
+	opts := options(dir, cfg)
+	if opts.Mode&^gen.Test == 0 {
+		return errors.New("no methods to generate")
+	}
+	return Run(opts)
+}
+
+// Run writes the methods for opts.File, and their tests, next
+// to it or to opts.Output, e.g.
 //
-//	err := msgp.Run("path/to/myfile.go", gen.Size|gen.Marshal|gen.Unmarshal|gen.Test, false)
-func Run(gofile string, mode gen.Method, unexported bool) error {
-	if mode&^gen.Test == 0 {
+//	err := Run(generate.Options{File: "path/to/myfile.go", Mode: gen.Size | gen.Marshal | gen.Unmarshal | gen.Test})
+func Run(opts generate.Options) error {
+	if opts.Mode&^gen.Test == 0 {
 		return nil
 	}
-	diagf("Input: \"%s\"\n", gofile)
-	fs, err := parse.File(gofile, unexported)
+	diagf("Input: \"%s\"\n", opts.File)
+	if opts.Config != nil {
+		diagf("Config: \"%s\"\n", opts.Config.Path)
+	}
+	out, err := generate.Write(opts)
 	if err != nil {
 		return err
 	}
-
-	if len(fs.Identities) == 0 {
-		diagf("No types requiring code generation were found!")
+	diagf("Wrote and formatted \"%s\"\n", out)
+	if opts.Mode&gen.Test == gen.Test {
+		diagf("Wrote and formatted \"%s\"\n", printer.TestFile(out))
 	}
+	return nil
+}
+
+// splitTags splits a -tags value, like the go command:
+// tags are separated by commas or, in older form, spaces.
+func splitTags(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+}
 
-	return printer.PrintFile(newFilename(gofile, fs.Package), fs, mode)
+// findConfig returns the configuration for the
+// input file or directory, or nil.
+func findConfig(file string) (*generate.Config, error) {
+	if fi, err := os.Stat(file); err != nil || !fi.IsDir() {
+		file = filepath.Dir(file)
+	}
+	return generate.FindConfig(file)
 }
 
 // picks a new file name based on input flags and input filename(s).
-func newFilename(old string, pkg string) string {
+func newFilename(old string, pkg string, cfg *generate.Config) string {
 	if *out != "" {
 		if pre := strings.TrimPrefix(*out, old); len(pre) > 0 &&
 			!strings.HasSuffix(*out, ".go") {
@@ -124,9 +280,5 @@ func newFilename(old string, pkg string) string {
 		return *out
 	}
 
-	if fi, err := os.Stat(old); err == nil && fi.IsDir() {
-		old = filepath.Join(old, pkg)
-	}
-	// new file name is old file name + _gen.go
-	return strings.TrimSuffix(old, ".go") + "_gen.go"
+	return cfg.OutputFile(old, pkg)
 }
@@ -4,9 +4,13 @@ import (
 	"fmt"
 	"go/ast"
 	"go/parser"
+	"go/scanner"
+	"go/token"
+	"strconv"
 	"strings"
 
 	"github.com/aggronmagi/csmsgp2go/gen"
+	"github.com/tinylib/msgp/msgp"
 )
 
 const linePrefix = "//msgp:"
@@ -14,8 +18,8 @@ const linePrefix = "//msgp:"
 // func(args, fileset)
 type directive func([]string, *FileSet) error
 
-// func(passName, args, printer)
-type passDirective func(gen.Method, []string, *gen.Printer) error
+// func(passName, args, printer, fileset)
+type passDirective func(gen.Method, []string, *gen.Printer, *FileSet) error
 
 // map of all recognized directives
 //
@@ -29,6 +33,11 @@ var directives = map[string]directive{
 	"compactfloats": compactfloats,
 	"clearomitted":  clearomitted,
 	"newtime":       newtime,
+	"deterministic": deterministic,
+	"timespan":      timespan,
+	"ext":           extension,
+	"maxlen":        maxlen,
+	"generate":      generateMarker,
 }
 
 // map of all recognized directives which will be applied
@@ -45,27 +54,109 @@ var passDirectives = map[string]passDirective{
 	"ignore": passignore,
 }
 
-func passignore(m gen.Method, text []string, p *gen.Printer) error {
-	pushstate(m.String())
+func passignore(m gen.Method, text []string, p *gen.Printer, f *FileSet) error {
+	f.pushstate(m.String())
 	for _, a := range text {
 		p.ApplyDirective(m, gen.IgnoreTypename(a))
-		infof("ignoring %s\n", a)
+		f.infof("ignoring %s\n", a)
 	}
-	popstate()
+	f.popstate()
 	return nil
 }
 
-// find all comment lines that begin with //msgp:
-func yieldComments(c []*ast.CommentGroup) []string {
-	var out []string
+// func(typeName, args, fileset)
+type typeDirective func(string, []string, *FileSet) error
+
+// map of the directives that may be written in the doc
+// comment of a type declaration, where they only apply
+// to that type. Other directives in a doc comment apply
+// to the whole file, as before.
+var typeDirectives = map[string]typeDirective{
+	"compactfloats": typeOption(func(o *gen.TypeOptions) { o.CompactFloats = true }),
+	"clearomitted":  typeOption(func(o *gen.TypeOptions) { o.ClearOmitted = true }),
+	"newtime":       typeOption(func(o *gen.TypeOptions) { o.NewTime = true }),
+	"deterministic": typeOption(func(o *gen.TypeOptions) { o.Deterministic = true }),
+	"timespan":      typeTimespan,
+	"maxlen":        typeMaxlen,
+	"ext":           typeExtension,
+}
+
+// A Directive is a //msgp: comment line.
+type Directive struct {
+	Args []string       // name and arguments
+	Pos  token.Position // position of the comment
+
+	shared bool // from Options.Directives
+}
+
+func (d Directive) String() string { return strings.Join(d.Args, " ") }
+
+// errorf returns an error at the position of d.
+func (d Directive) errorf(format string, args ...interface{}) error {
+	return &scanner.Error{Pos: d.Pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// yieldComments collects all comment lines that begin with
+// //msgp:. Directives in the doc comment of a type declaration
+// that are in typeDirectives are kept for that type.
+func (fs *FileSet) yieldComments(c []*ast.CommentGroup, docs map[*ast.CommentGroup]string) {
 	for _, cg := range c {
 		for _, line := range cg.List {
-			if strings.HasPrefix(line.Text, linePrefix) {
-				out = append(out, strings.TrimPrefix(line.Text, linePrefix))
+			if !strings.HasPrefix(line.Text, linePrefix) {
+				continue
 			}
+			d := Directive{Pos: fs.FSet.Position(line.Pos())}
+			args, err := splitDirective(strings.TrimPrefix(line.Text, linePrefix))
+			if err != nil {
+				fs.addError(d.errorf("%s", err))
+				continue
+			}
+			if len(args) == 0 {
+				fs.addError(d.errorf("empty directive"))
+				continue
+			}
+			d.Args = args
+			if name, ok := docs[cg]; ok {
+				if _, ok := typeDirectives[args[0]]; ok {
+					if fs.typeDirs == nil {
+						fs.typeDirs = make(map[string][]Directive)
+					}
+					fs.typeDirs[name] = append(fs.typeDirs[name], d)
+					continue
+				}
+			}
+			fs.Directives = append(fs.Directives, d)
 		}
 	}
-	return out
+}
+
+// splitDirective splits a directive into space separated
+// words. Double-quoted or back-quoted parts are unquoted and
+// may contain spaces, e.g. using:"a/b" or "some value".
+func splitDirective(s string) ([]string, error) {
+	var out []string
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return out, nil
+		}
+		var word strings.Builder
+		for s != "" && s[0] != ' ' && s[0] != '\t' {
+			if s[0] != '"' && s[0] != '`' {
+				word.WriteByte(s[0])
+				s = s[1:]
+				continue
+			}
+			q, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil, fmt.Errorf("unterminated quoted value %s", s)
+			}
+			v, _ := strconv.Unquote(q)
+			word.WriteString(v)
+			s = s[len(q):]
+		}
+		out = append(out, word.String())
+	}
 }
 
 //msgp:shim {Type} as:{NewType} using:{toFunc/fromFunc} mode:{Mode}
@@ -104,8 +195,9 @@ func applyShim(text []string, f *FileSet) error {
 		}
 	}
 
-	infof("%s -> %s\n", name, be.Value.String())
-	f.findShim(name, be, true)
+	f.infof("%s -> %s\n", name, be.Value.String())
+	// a shared shim only gets methods in the package declaring the type
+	f.findShim(name, be, !f.shared || f.Specs[name] != nil)
 
 	return nil
 }
@@ -138,7 +230,7 @@ func replace(text []string, f *FileSet) error {
 		}
 	}
 
-	infof("%s -> %s\n", name, replacement)
+	f.infof("%s -> %s\n", name, replacement)
 	f.findShim(name, e, false)
 
 	return nil
@@ -153,7 +245,7 @@ func ignore(text []string, f *FileSet) error {
 		name := strings.TrimSpace(item)
 		if _, ok := f.Identities[name]; ok {
 			delete(f.Identities, name)
-			infof("ignoring %s\n", name)
+			f.infof("ignoring %s\n", name)
 		}
 	}
 	return nil
@@ -193,20 +285,176 @@ func pointer(text []string, f *FileSet) error {
 	return nil
 }
 
-//msgp:compactfloats
-func compactfloats(text []string, f *FileSet) error {
-	f.CompactFloats = true
+//msgp:compactfloats [true|false]
+func compactfloats(text []string, f *FileSet) (err error) {
+	f.CompactFloats, err = switchArg(text)
+	return err
+}
+
+//msgp:clearomitted [true|false]
+func clearomitted(text []string, f *FileSet) (err error) {
+	f.ClearOmitted, err = switchArg(text)
+	return err
+}
+
+//msgp:generate
+func generateMarker(text []string, f *FileSet) error {
+	// only marks the package for -file ./...
 	return nil
 }
 
-//msgp:clearomitted
-func clearomitted(text []string, f *FileSet) error {
-	f.ClearOmitted = true
+//msgp:newtime [true|false]
+func newtime(text []string, f *FileSet) (err error) {
+	f.NewTime, err = switchArg(text)
+	return err
+}
+
+//msgp:timespan
+func timespan(text []string, f *FileSet) error {
+	f.findShim("time.Duration", timespanShim(), false)
 	return nil
 }
 
-//msgp:newtime
-func newtime(text []string, f *FileSet) error {
-	f.NewTime = true
+// timespanShim writes a time.Duration like a C# TimeSpan:
+// an int64 count of 100ns ticks.
+func timespanShim() *gen.BaseElem {
+	be := gen.Ident("int64")
+	be.Alias("time.Duration")
+	be.ShimToBase = "csmsgp.DurationToTicks"
+	be.ShimFromBase = "csmsgp.TicksToDuration"
+	be.ShimMode = gen.Convert
+	return be
+}
+
+//msgp:ext {Type} code:{Code}
+func extension(text []string, f *FileSet) error {
+	if len(text) != 3 {
+		return fmt.Errorf("ext directive should have 2 arguments; found %d", len(text)-1)
+	}
+	name := strings.TrimSpace(text[1])
+	if _, ok := f.Identities[name]; !ok {
+		return fmt.Errorf("ext type %s is not defined in this file", name)
+	}
+	codestr := strings.TrimPrefix(strings.TrimSpace(text[2]), "code:")
+	code, err := strconv.ParseInt(codestr, 10, 8)
+	if err != nil || code < 0 {
+		return fmt.Errorf("invalid ext code %q; expected 0 to 127", codestr)
+	}
+	switch code {
+	case msgp.Complex64Extension, msgp.Complex128Extension, msgp.TimeExtension:
+		return fmt.Errorf("ext code %d is reserved by msgp", code)
+	}
+	for other, c := range f.Exts {
+		if c == int8(code) && other != name {
+			return fmt.Errorf("ext code %d is used by both %s and %s", code, other, name)
+		}
+	}
+	if f.Exts == nil {
+		f.Exts = make(map[string]int8)
+	}
+	f.Exts[name] = int8(code)
+
+	// fields of the type are written as extensions
+	be := gen.Ident(name)
+	be.Value = gen.Ext
+	f.infof("%s -> ext %d\n", name, code)
+	f.findShim(name, be, false)
 	return nil
 }
+
+// typeOption returns a typeDirective without arguments
+// that sets a printer option for the type.
+func typeOption(set func(*gen.TypeOptions)) typeDirective {
+	return func(name string, text []string, f *FileSet) error {
+		if len(text) != 1 {
+			return fmt.Errorf("%s directive takes no arguments", text[0])
+		}
+		if f.typeOpts == nil {
+			f.typeOpts = make(map[string]gen.TypeOptions)
+		}
+		o := f.typeOpts[name]
+		set(&o)
+		f.typeOpts[name] = o
+		return nil
+	}
+}
+
+//msgp:timespan in a type's doc comment
+func typeTimespan(name string, text []string, f *FileSet) error {
+	if len(text) != 1 {
+		return fmt.Errorf("timespan directive takes no arguments")
+	}
+	el := f.Identities[name]
+	f.nextShim(&el, "time.Duration", timespanShim())
+	f.Identities[name] = el
+	return nil
+}
+
+//msgp:maxlen {N} in a type's doc comment
+func typeMaxlen(name string, text []string, f *FileSet) error {
+	n, err := parseMaxlen(text)
+	if err != nil {
+		return err
+	}
+	if f.typeOpts == nil {
+		f.typeOpts = make(map[string]gen.TypeOptions)
+	}
+	o := f.typeOpts[name]
+	o.MaxLen = n
+	f.typeOpts[name] = o
+	return nil
+}
+
+//msgp:ext code:{Code} in a type's doc comment
+func typeExtension(name string, text []string, f *FileSet) error {
+	if len(text) != 2 {
+		return fmt.Errorf("ext directive in a type comment should have 1 argument; found %d", len(text)-1)
+	}
+	return extension([]string{text[0], name, text[1]}, f)
+}
+
+//msgp:maxlen {N}
+func maxlen(text []string, f *FileSet) error {
+	n, err := parseMaxlen(text)
+	if err != nil {
+		return err
+	}
+	f.MaxLen = n
+	return nil
+}
+
+func parseMaxlen(text []string) (uint32, error) {
+	if len(text) != 2 {
+		return 0, fmt.Errorf("maxlen directive should have 1 argument; found %d", len(text)-1)
+	}
+	n, err := strconv.ParseUint(text[1], 10, 32)
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("invalid maxlen %q; expected a positive number", text[1])
+	}
+	return uint32(n), nil
+}
+
+//msgp:deterministic [true|false]
+func deterministic(text []string, f *FileSet) (err error) {
+	f.Deterministic, err = switchArg(text)
+	return err
+}
+
+// switchArg returns the setting of a file-wide switch like
+// //msgp:newtime: on without an argument, or as given by
+// true or false, so a file can turn off a switch set by
+// the configuration file.
+func switchArg(text []string) (bool, error) {
+	if len(text) == 1 {
+		return true, nil
+	}
+	if len(text) == 2 {
+		switch text[1] {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return false, fmt.Errorf("%s directive takes no argument, or true or false", text[0])
+}
@@ -0,0 +1,96 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"strings"
+)
+
+// ErrorList is the list of errors found in a FileSet,
+// sorted by position. Each error prints as
+// file:line:col: message, one per line.
+type ErrorList []*scanner.Error
+
+func (l ErrorList) Error() string {
+	lines := make([]string, len(l))
+	for i, e := range l {
+		lines[i] = e.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Unwrap returns the errors in l.
+func (l ErrorList) Unwrap() []error {
+	out := make([]error, len(l))
+	for i, e := range l {
+		out[i] = e
+	}
+	return out
+}
+
+// err returns the errors recorded so far, or nil.
+func (f *FileSet) err() error {
+	if len(f.errs) == 0 {
+		return nil
+	}
+	scanner.ErrorList(f.errs).Sort()
+	return f.errs
+}
+
+// position returns the position of n. While a directive is
+// applied, expressions parsed from its arguments have no
+// position in the file, so the directive's position is used.
+func (f *FileSet) position(n ast.Node) token.Position {
+	if f.dirPos.IsValid() {
+		return f.dirPos
+	}
+	if n == nil || f.FSet == nil {
+		return token.Position{}
+	}
+	return f.FSet.Position(n.Pos())
+}
+
+// errorAt returns an error at the position of n.
+func (f *FileSet) errorAt(n ast.Node, format string, args ...interface{}) error {
+	return posError(f.position(n), format, args...)
+}
+
+func posError(pos token.Position, format string, args ...interface{}) error {
+	return &scanner.Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// addError records err, which is usually from errorAt.
+func (f *FileSet) addError(err error) {
+	var se *scanner.Error
+	if !errors.As(err, &se) {
+		se = &scanner.Error{Msg: err.Error()}
+	}
+	f.errs = append(f.errs, se)
+}
+
+// warnAt logs a warning at the position of n, or records
+// it as an error in strict mode.
+func (f *FileSet) warnAt(n ast.Node, format string, args ...interface{}) {
+	f.warnAtPos(f.position(n), format, args...)
+}
+
+func (f *FileSet) warnAtPos(pos token.Position, format string, args ...interface{}) {
+	err := posError(pos, format, args...)
+	if f.strict {
+		f.addError(err)
+		return
+	}
+	f.warnf("%s\n", err)
+}
+
+// errMsg returns the message of err without its position.
+func errMsg(err error) string {
+	var se *scanner.Error
+	if errors.As(err, &se) {
+		return se.Msg
+	}
+	return err.Error()
+}
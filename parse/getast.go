@@ -2,13 +2,17 @@ package parse
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
 	"go/ast"
+	"go/build"
 	"go/parser"
 	"go/printer"
 	"go/token"
+	"go/types"
+	"math"
 	"os"
+	"path"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"strconv"
@@ -20,91 +24,192 @@ import (
 // A FileSet is the in-memory representation of a
 // parsed file.
 type FileSet struct {
-	Package       string              // package name
-	Specs         map[string]ast.Expr // type specs in file
-	Identities    map[string]gen.Elem // processed from specs
-	Directives    []string            // raw preprocessor directives
-	Imports       []*ast.ImportSpec   // imports
-	CompactFloats bool                // Use smaller floats when feasible
-	ClearOmitted  bool                // Set omitted fields to zero value
-	NewTime       bool                // Set to use -1 extension for time.Time
-	tagName       string              // tag to read field names from
-	pointerRcv    bool                // generate with pointer receivers.
+	Package       string                       // package name
+	Specs         map[string]ast.Expr          // type specs in file
+	Identities    map[string]gen.Elem          // processed from specs
+	Directives    []Directive                  // preprocessor directives
+	Imports       []*ast.ImportSpec            // imports
+	CompactFloats bool                         // Use smaller floats when feasible
+	ClearOmitted  bool                         // Set omitted fields to zero value
+	NewTime       bool                         // Set to use -1 extension for time.Time
+	Deterministic bool                         // Write map keys in sorted order
+	Exts          map[string]int8              // extension type codes
+	MaxLen        uint32                       // max decoded collection length, or 0
+	typeDirs      map[string][]Directive       // directives from type doc comments
+	typeOpts      map[string]gen.TypeOptions   // printer options set by typeDirs
+	tagName       string                       // tag to read field names from
+	pointerRcv    bool                         // generate with pointer receivers.
+	strict        bool                         // report warnings as errors
+	errs          ErrorList                    // errors found so far
+	dirPos        token.Position               // position of the directive being applied
+	shared        bool                         // the directive being applied is from Options.Directives
+	nonLocal      []identPos                   // identifiers not declared in the parsed files
+	dir           string                       // directory of the parsed files
+	methods       map[string]map[string]bool   // method names by receiver type
+	importer      types.ImporterFrom           // type-checks imported packages on demand
+	logf          func(string, ...interface{}) // logs info and warnings, or nil
+	logctx        []string                     // logging state
+	aliases       map[string]ast.Expr          // alias targets, by alias name
+	sibs          *siblings                    // declarations in the files not parsed
+	parsed        map[string]bool              // absolute paths of the parsed files
+	usedDefs      map[token.Position]bool      // identifiers whose definition in sibs was used
+	tags          []string                     // build tags
+	fileImports   map[string][]*ast.ImportSpec // imports of each file, by file name
 
 	FSet *token.FileSet // use for prompt error
 }
 
+// Options configure File and Source.
+type Options struct {
+	Unexported bool // also process unexported types
+	Strict     bool // report warnings as errors
+
+	// Tags are the build tags satisfied when parsing
+	// a directory, in addition to GOOS and GOARCH.
+	Tags []string
+
+	// Directives are applied before the directives of
+	// the files, which override them.
+	Directives []Directive
+
+	// Logf, if not nil, logs what is inferred from
+	// the source, and warnings.
+	Logf func(s string, v ...interface{})
+}
+
 // File parses a file at the relative path
 // provided and produces a new *FileSet.
-// If you pass in a path to a directory, the entire
-// directory will be parsed.
-// If unexport is false, only exported identifiers are included in the FileSet.
+// If you pass in a path to a directory, the files of the
+// package in it are parsed, like the go command would build
+// them with opts.Tags: files excluded by build constraints,
+// test files, and files generated by csmsgp2go are skipped.
+// Only exported identifiers are included in the FileSet,
+// unless opts.Unexported is set.
 // If the resulting FileSet would be empty, an error is returned.
-func File(name string, unexported bool) (*FileSet, error) {
-	pushstate(name)
-	defer popstate()
-	fs := &FileSet{
-		Specs:      make(map[string]ast.Expr),
-		Identities: make(map[string]gen.Elem),
-	}
-
+// Errors in the source are returned together as an ErrorList.
+func File(name string, opts Options) (*FileSet, error) {
 	fset := token.NewFileSet()
 	finfo, err := os.Stat(name)
 	if err != nil {
 		return nil, err
 	}
-	if finfo.IsDir() {
-		pkgs, err := parser.ParseDir(fset, name, nil, parser.ParseComments)
+	if !finfo.IsDir() {
+		f, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
 		if err != nil {
 			return nil, err
 		}
-		if len(pkgs) != 1 {
-			return nil, fmt.Errorf("multiple packages in directory: %s", name)
-		}
-		var one *ast.Package
-		for _, nm := range pkgs {
-			one = nm
-			break
-		}
-		fs.Package = one.Name
-		for _, fl := range one.Files {
-			pushstate(fl.Name.Name)
-			fs.Directives = append(fs.Directives, yieldComments(fl.Comments)...)
-			if !unexported {
-				ast.FileExports(fl)
-			}
-			fs.getTypeSpecs(fl)
-			popstate()
+		return newFileSet(fset, name, filepath.Dir(name), []*ast.File{f}, opts)
+	}
+	bctx := build.Default
+	bctx.BuildTags = opts.Tags
+	pkg, err := bctx.ImportDir(name, 0)
+	if err != nil {
+		return nil, err
+	}
+	names := append(append([]string(nil), pkg.GoFiles...), pkg.CgoFiles...)
+	sort.Strings(names)
+	var files []*ast.File
+	for _, fn := range names {
+		if strings.HasSuffix(fn, "_gen.go") {
+			continue
 		}
-	} else {
-		f, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
+		f, err := parser.ParseFile(fset, filepath.Join(name, fn), nil, parser.ParseComments)
 		if err != nil {
 			return nil, err
 		}
-		fs.Package = f.Name.Name
-		fs.Directives = yieldComments(f.Comments)
-		if !unexported {
-			ast.FileExports(f)
+		if !isGenerated(f) {
+			files = append(files, f)
 		}
-		fs.getTypeSpecs(f)
 	}
-
-	if len(fs.Specs) == 0 {
+	if len(files) == 0 {
 		return nil, fmt.Errorf("no definitions in %s", name)
 	}
+	return newFileSet(fset, name, name, files, opts)
+}
+
+// isGenerated reports whether f was written by csmsgp2go,
+// perhaps with a different output name. The marker is
+// written after the package clause.
+func isGenerated(f *ast.File) bool {
+	for _, cg := range f.Comments {
+		if len(f.Decls) > 0 && cg.Pos() > f.Decls[0].Pos() {
+			break
+		}
+		for _, c := range cg.List {
+			if c.Text == generatedHeader {
+				return true
+			}
+		}
+	}
+	return false
+}
 
-	fs.FSet = fset
+// generatedHeader is the first comment of the files generated by gen.
+const generatedHeader = "// Code generated by github.com/aggronmagi/csmsgp2go DO NOT EDIT."
 
-	if err = fs.applyEarlyDirectives(); err != nil {
+// Source is like File, but parses the Go source src
+// instead of reading it. name is the file name used
+// in errors, and to find the packages it imports.
+func Source(name string, src []byte, opts Options) (*FileSet, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+	if err != nil {
 		return nil, err
 	}
-	if err = fs.process(); err != nil {
-		return nil, err
+	return newFileSet(fset, name, filepath.Dir(name), []*ast.File{f}, opts)
+}
+
+// newFileSet processes the files of a package, parsed
+// from name in dir.
+func newFileSet(fset *token.FileSet, name, dir string, files []*ast.File, opts Options) (*FileSet, error) {
+	fs := &FileSet{
+		Specs:      make(map[string]ast.Expr),
+		Identities: make(map[string]gen.Elem),
+		FSet:       fset,
+		strict:     opts.Strict,
+		dir:        dir,
+		logf:       opts.Logf,
+		tags:       opts.Tags,
+		Package:    files[0].Name.Name,
+		aliases:    make(map[string]ast.Expr),
+		parsed:     make(map[string]bool),
+		usedDefs:   make(map[token.Position]bool),
+
+		fileImports: make(map[string][]*ast.ImportSpec),
+	}
+	for _, d := range opts.Directives {
+		d.shared = true
+		fs.Directives = append(fs.Directives, d)
+	}
+	fs.pushstate(name)
+	defer fs.popstate()
+	for _, fl := range files {
+		fs.pushstate(fl.Name.Name)
+		fs.parsed[absPath(fset.Position(fl.Package).Filename)] = true
+		fs.yieldComments(fl.Comments, typeDocs(fl))
+		// unexported aliases may stand for exported types
+		fs.collectAliases(fl)
+		if !opts.Unexported {
+			ast.FileExports(fl)
+		}
+		fs.getTypeSpecs(fl)
+		fs.popstate()
+	}
+
+	if len(fs.Specs) == 0 {
+		return nil, fmt.Errorf("no definitions in %s", name)
 	}
-	if err = fs.applyDirectives(); err != nil {
+
+	fs.applyEarlyDirectives()
+	fs.process()
+	fs.applyDirectives()
+	fs.marshalerShims()
+	fs.checkIdents()
+	if err := fs.err(); err != nil {
 		return nil, err
 	}
-	if err = fs.propInline(); err != nil {
+	fs.propInline()
+	if err := fs.err(); err != nil {
 		return nil, err
 	}
 
@@ -121,46 +226,94 @@ func (f *FileSet) Format(node interface{}) string {
 }
 
 func (f *FileSet) sortAndFillMsgFields() {
-	spaceHolder := &gen.NilPlaceholder{}
 	for _, elem := range f.Identities {
-		// fix struct
 		if s, ok := elem.(*gen.Struct); ok {
-			if len(s.Fields) < 1 {
-				continue
-			}
-			flagSet := make(map[uint16]struct{})
-			maxId := uint16(0)
-			for _, field := range s.Fields {
-				flagSet[field.FieldTag] = struct{}{}
-				if field.FieldTag > maxId {
-					maxId = field.FieldTag
-				}
+			for i := range s.Fields {
+				field := &s.Fields[i]
 				field.FieldElem = fixCsharpString(field.FieldElem)
 			}
-			if int(maxId)+1 == len(s.Fields) {
-				continue
+		}
+		fillMsgFields(elem)
+	}
+}
+
+// fillMsgFields sorts the fields of every struct in elem
+// by index and fills each run of unused indexes with a
+// single NilPlaceholder. Structs inlined into other
+// types are copies, so they are walked as well.
+func fillMsgFields(elem gen.Elem) {
+	switch v := elem.(type) {
+	case *gen.Struct:
+		fields := v.Fields[:0]
+		for _, field := range v.Fields {
+			if _, ok := field.FieldElem.(*gen.NilPlaceholder); !ok {
+				fillMsgFields(field.FieldElem)
+				fields = append(fields, field)
 			}
-			for i := uint16(0); i <= maxId; i++ {
-				if _, ok := flagSet[i]; ok {
-					continue
-				}
-				s.Fields = append(s.Fields, gen.StructField{
-					FieldTag:  i,
-					FieldElem: spaceHolder,
+		}
+		sort.SliceStable(fields, func(i, j int) bool {
+			return fields[i].FieldTag < fields[j].FieldTag
+		})
+		filled := make([]gen.StructField, 0, len(fields))
+		next := 0 // next unused index
+		for _, field := range fields {
+			if gap := int(field.FieldTag) - next; gap > 0 {
+				filled = append(filled, gen.StructField{
+					FieldTag:  uint16(next),
+					FieldElem: &gen.NilPlaceholder{Count: gap},
 				})
 			}
-			sort.Slice(s.Fields, func(i, j int) bool {
-				return s.Fields[i].FieldTag < s.Fields[j].FieldTag
-			})
+			filled = append(filled, field)
+			next = int(field.FieldTag) + 1
 		}
+		v.Fields = filled
+	case *gen.Ptr:
+		fillMsgFields(v.Value)
+	case *gen.Map:
+		fillMsgFields(v.Value)
+	case *gen.Slice:
+		fillMsgFields(v.Els)
+	case *gen.Array:
+		fillMsgFields(v.Els)
+	}
+}
+
+// dupFieldTag returns an index used by more than one field.
+func dupFieldTag(fields []gen.StructField) (uint16, bool) {
+	seen := make(map[uint16]bool, len(fields))
+	for i := range fields {
+		tag := fields[i].FieldTag
+		if seen[tag] {
+			return tag, true
+		}
+		seen[tag] = true
 	}
+	return 0, false
+}
+
+// sortMaps marks every map in elem to be written with
+// sorted keys and reports whether there was any.
+func sortMaps(elem gen.Elem) bool {
+	switch v := elem.(type) {
+	case *gen.Map:
+		v.Sorted = true
+		sortMaps(v.Value)
+		return true
+	case *gen.Slice:
+		return sortMaps(v.Els)
+	case *gen.Array:
+		return sortMaps(v.Els)
+	}
+	return false
 }
 
 func fixCsharpString(elem gen.Elem) gen.Elem {
 	switch v := elem.(type) {
 	case *gen.BaseElem:
-		if v.Value == gen.String {
-			return &gen.CsharpString{}
+		if v.Value == gen.String && v.ShimToBase == "" {
+			cs := &gen.CsharpString{}
+			cs.Alias(v.TypeName())
+			return cs
 		}
 	case *gen.Ptr:
 		v.Value = fixCsharpString(v.Value)
@@ -180,54 +333,66 @@ func fixCsharpString(elem gen.Elem) gen.Elem {
 }
 
 // applyDirectives applies all of the directives that
-// are known to the parser. additional method-specific
-// directives remain in f.Directives
-func (f *FileSet) applyDirectives() error {
-	newdirs := make([]string, 0, len(f.Directives))
+// are known to the parser, then the directives from type
+// doc comments. additional method-specific directives
+// remain in f.Directives; anything else is an error.
+func (f *FileSet) applyDirectives() {
+	newdirs := make([]Directive, 0, len(f.Directives))
 	for _, d := range f.Directives {
-		chunks := strings.Split(d, " ")
-		if len(chunks) > 0 {
-			if fn, ok := directives[chunks[0]]; ok {
-				pushstate(chunks[0])
-				err := fn(chunks, f)
-				if err != nil {
-					warnf("directive error: %s", err)
-					return fmt.Errorf("directive %v error: %w", chunks, err)
-				}
-				popstate()
-			} else {
-				newdirs = append(newdirs, d)
-			}
+		if fn, ok := directives[d.Args[0]]; ok {
+			f.applyDirective(d, func() error { return fn(d.Args, f) })
+		} else if len(d.Args) > 1 && strToMethod(d.Args[0]) != 0 && passDirectives[d.Args[1]] != nil {
+			newdirs = append(newdirs, d)
+		} else {
+			f.addError(d.errorf("unknown directive %q", d.String()))
 		}
 	}
 	f.Directives = newdirs
-	return nil
+
+	names := make([]string, 0, len(f.typeDirs))
+	for name := range f.typeDirs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := f.Identities[name]; !ok {
+			// ignored or not processed
+			continue
+		}
+		f.pushstate(name)
+		for _, d := range f.typeDirs[name] {
+			f.applyDirective(d, func() error { return typeDirectives[d.Args[0]](name, d.Args, f) })
+		}
+		f.popstate()
+	}
 }
 
 // applyEarlyDirectives applies all early directives needed before process() is called.
 // additional directives remain in f.Directives for future processing
-func (f *FileSet) applyEarlyDirectives() error {
-	newdirs := make([]string, 0, len(f.Directives))
+func (f *FileSet) applyEarlyDirectives() {
+	newdirs := make([]Directive, 0, len(f.Directives))
 	for _, d := range f.Directives {
-		parts := strings.Split(d, " ")
-		if len(parts) == 0 {
-			continue
-		}
-		if fn, ok := earlyDirectives[parts[0]]; ok {
-			pushstate(parts[0])
-			err := fn(parts, f)
-			if err != nil {
-				warnf("early directive error: %s", err)
-				err = fmt.Errorf("early directive error: %w", err)
-				return err
-			}
-			popstate()
+		if fn, ok := earlyDirectives[d.Args[0]]; ok {
+			f.applyDirective(d, func() error { return fn(d.Args, f) })
 		} else {
 			newdirs = append(newdirs, d)
 		}
 	}
 	f.Directives = newdirs
-	return nil
+}
+
+// applyDirective calls apply for d and records its error
+// at the position of d.
+func (f *FileSet) applyDirective(d Directive, apply func() error) {
+	f.pushstate(d.Args[0])
+	f.dirPos, f.shared = d.Pos, d.shared
+	err := apply()
+	f.dirPos, f.shared = token.Position{}, false
+	f.popstate()
+	if err != nil {
+		f.warnf("directive error: %s\n", err)
+		f.addError(d.errorf("%s directive: %s", d.Args[0], errMsg(err)))
+	}
 }
 
 // A linkset is a graph of unresolved
@@ -253,7 +418,12 @@ func (f *FileSet) applyEarlyDirectives() error {
 // figuring out that D is just a uint64.
 type linkset map[string]*gen.BaseElem
 
-func (f *FileSet) resolve(ls linkset) error {
+func (f *FileSet) resolve(ls linkset) {
+	// the expression each name is linked by
+	links := make(map[string]ast.Expr, len(ls))
+	for name := range ls {
+		links[name] = f.Specs[name]
+	}
 	progress := true
 	for progress && len(ls) > 0 {
 		progress = false
@@ -269,37 +439,83 @@ func (f *FileSet) resolve(ls linkset) error {
 				nt.Alias(name)
 				f.Identities[name] = nt
 				delete(ls, name)
+			} else if def, _, ok := f.sibling(elem.TypeName()); ok {
+				// declared in another file of the package:
+				// use its definition, which may link further
+				progress = true
+				f.usedDefs[f.position(links[name])] = true
+				links[name] = def
+				f.defineFrom(ls, name, def)
 			}
 		}
 	}
 
-	if len(ls) < 1 {
-		return nil
-	}
-
 	// what's left can't be resolved
-	errTip := "couldn't resolve type."
 	for name, elem := range ls {
-		warnf("couldn't resolve type %s (%s)\n", name, elem.TypeName())
-		errTip += fmt.Sprintf(" %s-(%s)", name, elem.TypeName())
+		f.addError(f.errorAt(f.Specs[name], "couldn't resolve type %s (%s)", name, elem.TypeName()))
+	}
+}
+
+// defineFrom defines name, which is in ls, as def.
+func (f *FileSet) defineFrom(ls linkset, name string, def ast.Expr) {
+	el, err := f.parseExpr(def)
+	switch {
+	case err != nil:
+		f.addError(err)
+	case el == nil:
+		f.addError(f.errorAt(f.Specs[name], "type %s: unsupported type %s", name, f.Format(def)))
+	default:
+		if be, ok := el.(*gen.BaseElem); ok && be.Value == gen.IDENT {
+			ls[name] = be
+			return
+		}
+		el.AlwaysPtr(&f.pointerRcv)
+		el.Alias(name)
+		f.Identities[name] = el
+	}
+	delete(ls, name)
+}
+
+// identPos is an identifier and where it is used.
+type identPos struct {
+	name string
+	pos  token.Position
+}
+
+// checkIdents reports the identifiers that are not declared
+// in the parsed files: an error if nothing resolved them, or
+// a warning if a directive or an automatic shim did.
+func (f *FileSet) checkIdents() {
+	unresolved := f.unresolved()
+	for _, id := range f.nonLocal {
+		if _, ok := unresolved[id.name]; ok {
+			f.addError(posError(id.pos, "unresolved identifier %s", id.name))
+		} else if f.usedDefs[id.pos] {
+			// its definition was used
+		} else if file := f.siblingFile(id.name); file != "" {
+			f.warnAtPos(id.pos, "identifier %s is declared in %s, outside the parsed files", id.name, file)
+		} else {
+			f.warnAtPos(id.pos, "non-local identifier %s", id.name)
+		}
 	}
-	return errors.New(errTip)
 }
 
 // process takes the contents of f.Specs and
 // uses them to populate f.Identities
-func (f *FileSet) process() error {
+func (f *FileSet) process() {
 	deferred := make(linkset)
 parse:
 	for name, def := range f.Specs {
-		pushstate(name)
+		f.pushstate(name)
 		el, err := f.parseExpr(def)
 		if err != nil {
-			return fmt.Errorf("parse %s failed,%w", f.Format(def), err)
+			f.addError(err)
+			f.popstate()
+			continue parse
 		}
 		if el == nil {
-			warnf("failed to parse %s", f.Format(def))
-			popstate()
+			f.warnAt(def, "type %s ignored: unsupported type %s", name, f.Format(def))
+			f.popstate()
 			continue parse
 		}
 		el.AlwaysPtr(&f.pointerRcv)
@@ -308,18 +524,17 @@ parse:
 		// we've handled every possible named type.
 		if be, ok := el.(*gen.BaseElem); ok && be.Value == gen.IDENT {
 			deferred[name] = be
-			popstate()
+			f.popstate()
 			continue parse
 		}
 		el.Alias(name)
 		f.Identities[name] = el
-		popstate()
+		f.popstate()
 	}
 
 	if len(deferred) > 0 {
-		return f.resolve(deferred)
+		f.resolve(deferred)
 	}
-	return nil
 }
 
 func strToMethod(s string) gen.Method {
@@ -336,6 +551,12 @@ func strToMethod(s string) gen.Method {
 		return gen.Marshal
 	case "unmarshal":
 		return gen.Unmarshal
+	case "exactsize":
+		return gen.ExactSize
+	case "stream":
+		return gen.Stream
+	case "diff":
+		return gen.Diff
 	default:
 		return 0
 	}
@@ -346,35 +567,28 @@ func (f *FileSet) applyDirs(p *gen.Printer) {
 	//
 	// 	//msgp:encode ignore {{TypeName}}
 	//
-loop:
+	// they were validated by applyDirectives.
 	for _, d := range f.Directives {
-		chunks := strings.Split(d, " ")
-		if len(chunks) > 1 {
-			for i := range chunks {
-				chunks[i] = strings.TrimSpace(chunks[i])
-			}
-			m := strToMethod(chunks[0])
-			if m == 0 {
-				warnf("unknown pass name: %q\n", chunks[0])
-				continue loop
-			}
-			if fn, ok := passDirectives[chunks[1]]; ok {
-				pushstate(chunks[1])
-				err := fn(m, chunks[2:], p)
-				if err != nil {
-					warnf("error applying directive: %s\n", err)
-				}
-				popstate()
-			} else {
-				warnf("unrecognized directive %q\n", chunks[1])
-			}
-		} else {
-			warnf("empty directive: %q\n", d)
+		f.pushstate(d.Args[1])
+		err := passDirectives[d.Args[1]](strToMethod(d.Args[0]), d.Args[2:], p, f)
+		if err != nil {
+			f.warnf("error applying directive: %s\n", err)
 		}
+		f.popstate()
 	}
 	p.CompactFloats = f.CompactFloats
 	p.ClearOmitted = f.ClearOmitted
 	p.NewTime = f.NewTime
+	p.Deterministic = f.Deterministic
+	p.Exts = f.Exts
+	p.MaxLen = f.MaxLen
+	p.Types = f.typeOpts
+	p.Structs = make(map[string]bool)
+	for name, el := range f.Identities {
+		if _, ok := el.(*gen.Struct); ok {
+			p.Structs[name] = true
+		}
+	}
 }
 
 func (f *FileSet) PrintTo(p *gen.Printer) error {
@@ -386,10 +600,9 @@ func (f *FileSet) PrintTo(p *gen.Printer) error {
 	sort.Strings(names)
 	for _, name := range names {
 		el := f.Identities[name]
-		el.SetVarname("z")
-		pushstate(el.TypeName())
+		f.pushstate(el.TypeName())
 		err := p.Print(el)
-		popstate()
+		f.popstate()
 		if err != nil {
 			return err
 		}
@@ -397,11 +610,37 @@ func (f *FileSet) PrintTo(p *gen.Printer) error {
 	return nil
 }
 
+// typeDocs maps the doc comment of each type
+// declaration in f to the name of the type.
+func typeDocs(f *ast.File) map[*ast.CommentGroup]string {
+	docs := make(map[*ast.CommentGroup]string)
+	for _, decl := range f.Decls {
+		g, ok := decl.(*ast.GenDecl)
+		if !ok || g.Tok != token.TYPE {
+			continue
+		}
+		for _, s := range g.Specs {
+			ts := s.(*ast.TypeSpec)
+			doc := ts.Doc
+			if doc == nil && !g.Lparen.IsValid() {
+				// type T ... without parentheses
+				doc = g.Doc
+			}
+			if doc != nil {
+				docs[doc] = ts.Name.Name
+			}
+		}
+	}
+	return docs
+}
+
 // getTypeSpecs extracts all of the *ast.TypeSpecs in the file
 // into fs.Identities, but does not set the actual element
 func (fs *FileSet) getTypeSpecs(f *ast.File) {
 	// collect all imports...
 	fs.Imports = append(fs.Imports, f.Imports...)
+	fs.fileImports[fs.FSet.Position(f.Package).Filename] = f.Imports
+	fs.collectMethods(f)
 
 	// check all declarations...
 	for i := range f.Decls {
@@ -411,6 +650,10 @@ func (fs *FileSet) getTypeSpecs(f *ast.File) {
 			for _, s := range g.Specs {
 				// for ast.TypeSpecs....
 				if ts, ok := s.(*ast.TypeSpec); ok {
+					if ts.Assign.IsValid() {
+						// aliases have the methods of their target
+						continue
+					}
 					switch ts.Type.(type) {
 					// this is the list of parse-able
 					// type specs
@@ -427,6 +670,19 @@ func (fs *FileSet) getTypeSpecs(f *ast.File) {
 	}
 }
 
+// collectAliases records the type aliases declared in f.
+func (fs *FileSet) collectAliases(f *ast.File) {
+	for _, d := range f.Decls {
+		if g, ok := d.(*ast.GenDecl); ok && g.Tok == token.TYPE {
+			for _, s := range g.Specs {
+				if ts := s.(*ast.TypeSpec); ts.Assign.IsValid() {
+					fs.aliases[ts.Name.Name] = ts.Type
+				}
+			}
+		}
+	}
+}
+
 func fieldName(f *ast.Field) string {
 	switch len(f.Names) {
 	case 0:
@@ -438,29 +694,37 @@ func fieldName(f *ast.Field) string {
 	}
 }
 
-func (fs *FileSet) parseFieldList(fl *ast.FieldList, maxIdx *uint16) ([]gen.StructField, error) {
+func (fs *FileSet) parseFieldList(fl *ast.FieldList, maxIdx *int) ([]gen.StructField, error) {
 	if fl == nil || fl.NumFields() == 0 {
 		return nil, nil
 	}
 	out := make([]gen.StructField, 0, fl.NumFields())
 	for _, field := range fl.List {
-		pushstate(fieldName(field))
+		fs.pushstate(fieldName(field))
 		fds, err := fs.getField(field, maxIdx)
 		if err != nil {
-			return nil, fmt.Errorf("field %s parse failed,%w", fs.Format(fl), err)
-		}
-		if len(fds) > 0 {
-			out = append(out, fds...)
-		} else {
-			warnf("ignored")
+			// report every bad field, not just the first
+			fs.addError(err)
 		}
-		popstate()
+		out = append(out, fds...)
+		fs.popstate()
 	}
 	return out, nil
 }
 
+// nextIndex returns the index of a field without one in its
+// tag, the one after the previous field's, and advances *next.
+func (fs *FileSet) nextIndex(n ast.Node, next *int) (uint16, error) {
+	if *next > math.MaxUint16 {
+		return 0, fs.errorAt(n, "field index %d out of range: the previous field has index 65535", *next)
+	}
+	idx := uint16(*next)
+	*next++
+	return idx, nil
+}
+
 // translate *ast.Field into []gen.StructField
-func (fs *FileSet) getField(f *ast.Field, maxIdx *uint16) (sf []gen.StructField, err error) {
+func (fs *FileSet) getField(f *ast.Field, maxIdx *int) (sf []gen.StructField, err error) {
 	sf = make([]gen.StructField, 1)
 	var extension, flatten bool
 	// parse tag; otherwise field name is field tag
@@ -493,13 +757,12 @@ func (fs *FileSet) getField(f *ast.Field, maxIdx *uint16) (sf []gen.StructField,
 			if len(tags[0]) > 0 {
 				idx, err := strconv.ParseUint(tags[0], 10, 16)
 				if err != nil {
-					return nil, fmt.Errorf("invalid index %q: %w", tags[0], err)
+					return nil, fs.errorAt(f.Tag, "invalid index %q: expected 0 to 65535", tags[0])
 				}
 				sf[0].FieldTag = uint16(idx)
-				*maxIdx = uint16(idx) + 1
-			} else {
-				sf[0].FieldTag = *maxIdx
-				*maxIdx++
+				*maxIdx = int(idx) + 1
+			} else if sf[0].FieldTag, err = fs.nextIndex(f, maxIdx); err != nil {
+				return nil, err
 			}
 
 			sf[0].FieldTagParts = tags
@@ -508,17 +771,16 @@ func (fs *FileSet) getField(f *ast.Field, maxIdx *uint16) (sf []gen.StructField,
 			if len(tags[0]) > 0 {
 				idx, err := strconv.ParseUint(tags[0], 10, 16)
 				if err != nil {
-					return nil, fmt.Errorf("invalid index %q: %w", tags[0], err)
+					return nil, fs.errorAt(f.Tag, "invalid index %q: expected 0 to 65535", tags[0])
 				}
-				*maxIdx = uint16(idx)
+				*maxIdx = int(idx)
 			}
 			if len(f.Names) != 0 {
-				return nil, fmt.Errorf("flatten field must be anonymous field")
+				return nil, fs.errorAt(f, "flatten field must be anonymous field")
 			}
 		}
-	} else {
-		sf[0].FieldTag = *maxIdx
-		*maxIdx++
+	} else if sf[0].FieldTag, err = fs.nextIndex(f, maxIdx); err != nil {
+		return nil, err
 	}
 
 	ex, err := fs.parseExpr(f.Type)
@@ -526,6 +788,7 @@ func (fs *FileSet) getField(f *ast.Field, maxIdx *uint16) (sf []gen.StructField,
 		return nil, err
 	}
 	if ex == nil {
+		fs.warnAt(f, "field %s ignored: unsupported type %s", fieldName(f), fs.Format(f.Type))
 		return nil, nil
 	}
 
@@ -545,12 +808,15 @@ func (fs *FileSet) getField(f *ast.Field, maxIdx *uint16) (sf []gen.StructField,
 		// e.g. type A struct { One, Two int }
 		sf = sf[0:0]
 		for _, nm := range f.Names {
+			tag, err := fs.nextIndex(nm, maxIdx)
+			if err != nil {
+				return nil, err
+			}
 			sf = append(sf, gen.StructField{
-				FieldTag:  *maxIdx, //nm.Name,
+				FieldTag:  tag, //nm.Name,
 				FieldName: nm.Name,
 				FieldElem: ex.Copy(),
 			})
-			*maxIdx++
 		}
 		return sf, nil
 	}
@@ -564,6 +830,50 @@ func (fs *FileSet) getField(f *ast.Field, maxIdx *uint16) (sf []gen.StructField,
 	// 	}
 	// }
 
+	// validate lazy
+	typ, ok := sf[0].TagPartValue("lazy")
+	if ok || sf[0].HasTagPart("lazy") {
+		if be, ok := ex.(*gen.BaseElem); !ok || be.TypeName() != "msgp.Raw" {
+			// the field keeps the bytes, so it can't have the decoded type
+			return nil, fs.errorAt(f, "lazy field %s must be declared as msgp.Raw, with the decoded type in the tag: %s msgp.Raw `msg:\"%d,lazy:%s\"`",
+				sf[0].FieldName, sf[0].FieldName, sf[0].FieldTag, fs.Format(f.Type))
+		}
+		if typ == "" {
+			return nil, fs.errorAt(f.Tag, "lazy field %s: missing type, expected lazy:{Type}", sf[0].FieldName)
+		}
+		if sf[0].FieldName == "Msg" {
+			return nil, fs.errorAt(f, "lazy field can't be named Msg; DecodeMsg is taken")
+		}
+	}
+
+	// TimeSpan ticks
+	if sf[0].HasTagPart("timespan") {
+		if !strings.Contains(ex.TypeName(), "time.Duration") {
+			return nil, fs.errorAt(f, "timespan field %s has no time.Duration", sf[0].FieldName)
+		}
+		fs.nextShim(&ex, "time.Duration", timespanShim())
+		sf[0].FieldElem = ex
+	}
+
+	// sorted map keys
+	if sf[0].HasTagPart("sorted") {
+		if !sortMaps(ex) {
+			return nil, fs.errorAt(f, "sorted field %s has no map", sf[0].FieldName)
+		}
+	}
+
+	// validate set
+	if sf[0].HasTagPart("set") {
+		m, ok := ex.(*gen.Map)
+		if !ok {
+			return nil, fs.errorAt(f, "set field %s must be a map", sf[0].FieldName)
+		}
+		if vb, ok := m.Value.(*gen.BaseElem); !m.IsSet && (!ok || vb.Value != gen.Bool) {
+			return nil, fs.errorAt(f, "set field %s must be a map[K]struct{} or map[K]bool", sf[0].FieldName)
+		}
+		m.IsSet = true
+	}
+
 	// validate extension
 	if extension {
 		switch ex := ex.(type) {
@@ -571,20 +881,18 @@ func (fs *FileSet) getField(f *ast.Field, maxIdx *uint16) (sf []gen.StructField,
 			if b, ok := ex.Value.(*gen.BaseElem); ok {
 				b.Value = gen.Ext
 			} else {
-				warnf("couldn't cast to extension.")
-				return nil, fmt.Errorf("couldn't cast to extension %s.", fs.Format(ex.Value))
+				return nil, fs.errorAt(f, "field %s: couldn't cast %s to extension", sf[0].FieldName, ex.Value.TypeName())
 			}
 		case *gen.BaseElem:
 			ex.Value = gen.Ext
 		default:
-			warnf("couldn't cast to extension.")
-			return nil, fmt.Errorf("couldn't cast to extension %s.", fs.Format(ex))
+			return nil, fs.errorAt(f, "field %s: couldn't cast %s to extension", sf[0].FieldName, ex.TypeName())
 		}
 	}
 	return sf, nil
 }
 
-func (fs *FileSet) getFieldsFromEmbeddedStruct(f ast.Expr, maxIdx *uint16) ([]gen.StructField, error) {
+func (fs *FileSet) getFieldsFromEmbeddedStruct(f ast.Expr, maxIdx *int) ([]gen.StructField, error) {
 	switch f := f.(type) {
 	case *ast.Ident:
 		s := fs.Specs[f.Name]
@@ -592,16 +900,11 @@ func (fs *FileSet) getFieldsFromEmbeddedStruct(f ast.Expr, maxIdx *uint16) ([]ge
 		case *ast.StructType:
 			return fs.parseFieldList(s.Fields, maxIdx)
 		default:
-			warnf("%s disabled, not struct type", fs.Format(f))
-			return nil, fmt.Errorf("%s not struct type", fs.Format(f))
+			return nil, fs.errorAt(f, "flatten field %s is not a local struct type", f.Name)
 		}
-	case *ast.StarExpr:
-		warnf("%s disabled, StarExpr not support type", fs.Format(f))
-		return nil, fmt.Errorf("%s not support StarExpr type", fs.Format(f))
 	default:
 		// other possibilities are disallowed
-		warnf("%s other possibilities are disallowed", fs.Format(f))
-		return nil, fmt.Errorf("%s. other possibilities are disallowed", fs.Format(f)) // errors.New("other possibilities are disallowed")
+		return nil, fs.errorAt(f, "flatten field %s is not a local struct type", fs.Format(f))
 	}
 }
 
@@ -628,6 +931,22 @@ func embedded(f ast.Expr) string {
 	}
 }
 
+// importPath returns the path imported as name by the file containing n, or "".
+func (fs *FileSet) importPath(n ast.Node, name string) string {
+	for _, imp := range fs.fileImports[fs.FSet.Position(n.Pos()).Filename] {
+		ipath, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		if imp.Name != nil && imp.Name.Name == name ||
+			imp.Name == nil && path.Base(ipath) == name {
+			// the package name usually matches the last path element
+			return ipath
+		}
+	}
+	return ""
+}
+
 // stringify a field type name
 func stringify(e ast.Expr) string {
 	switch e := e.(type) {
@@ -671,22 +990,27 @@ func (fs *FileSet) parseExpr(e ast.Expr) (gen.Elem, error) {
 		if kt == nil {
 			return nil, nil
 		}
-		// check map key valid key
-		kname := kt.TypeName()
-		if kname != "string" && !strings.HasPrefix(kname, "uint") && !strings.HasPrefix(kname, "int") {
-			// 仅支持 string,int...,uint...
-			return nil, errors.New("map key only support string, int...,uint...")
+		// check map key valid key. named types are checked
+		// once they are resolved, see (*FileSet).nextInline.
+		if kb, ok := kt.(*gen.BaseElem); !ok || kb.Value != gen.IDENT {
+			if !validMapKey(kt) {
+				// 仅支持 string,int...,uint...
+				return nil, fs.errorAt(e.Key, "map key %s must be a string or integer type", fs.Format(e.Key))
+			}
 		}
 
 		// parse value type
 		value, err := fs.parseExpr(e.Value)
 		if err != nil {
-			return nil, fmt.Errorf("map value parse failed,%w", err)
+			return nil, err
 		}
 		if value != nil {
-			return &gen.Map{Key: kt, Value: value}, nil
+			// map[K]struct{} is a set, see C# HashSet<T>
+			st, isSet := value.(*gen.Struct)
+			isSet = isSet && len(st.Fields) == 0
+			return &gen.Map{Key: kt, Value: value, IsSet: isSet}, nil
 		}
-		return nil, fmt.Errorf("not support map value type")
+		return nil, fs.errorAt(e.Value, "unsupported map value type %s", fs.Format(e.Value))
 
 	case *ast.Ident:
 		b := gen.Ident(e.Name)
@@ -695,10 +1019,13 @@ func (fs *FileSet) parseExpr(e ast.Expr) (gen.Elem, error) {
 		// can be done later, once we've resolved
 		// everything else.
 		if b.Value == gen.IDENT {
+			if def, ok := fs.aliasTarget(e.Name); ok {
+				// an alias is its target
+				return fs.parseExpr(def)
+			}
 			if _, ok := fs.Specs[e.Name]; !ok {
-				warnf("non-local identifier: %s\n", e.Name)
-			} else {
-				println("local identifier: ", e.Name)
+				// checked by checkIdents
+				fs.nonLocal = append(fs.nonLocal, identPos{e.Name, fs.position(e)})
 			}
 		}
 		return b, nil
@@ -757,10 +1084,14 @@ func (fs *FileSet) parseExpr(e ast.Expr) (gen.Elem, error) {
 		// }
 		// return nil, fmt.Errorf("star expr [%s] parse failed", fs.Format(e))
 		// NOTE: 不支持指针类型,否则会导致行为和csharp不一致
-		return nil, fmt.Errorf("not support star expr [%s]", fs.Format(e))
+		// *big.Int is the exception; nil is written as 0.
+		if b := gen.Ident(stringify(e)); b.Value == gen.BigIntPtr {
+			return b, nil
+		}
+		return nil, fs.errorAt(e, "unsupported pointer type %s", fs.Format(e))
 
 	case *ast.StructType:
-		var maxIdx uint16
+		var maxIdx int
 		fields, err := fs.parseFieldList(e.Fields, &maxIdx)
 		if err != nil {
 			return nil, err
@@ -769,6 +1100,10 @@ func (fs *FileSet) parseExpr(e ast.Expr) (gen.Elem, error) {
 		return &gen.Struct{Fields: fields}, nil
 
 	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok && gen.IsUnityType(e.Sel.Name) &&
+			fs.importPath(e, x.Name) == gen.UnityPath {
+			return gen.UnityIdent(stringify(e)), nil
+		}
 		return gen.Ident(stringify(e)), nil
 
 	case *ast.InterfaceType:
@@ -776,39 +1111,35 @@ func (fs *FileSet) parseExpr(e ast.Expr) (gen.Elem, error) {
 		if len(e.Methods.List) == 0 {
 			return &gen.BaseElem{Value: gen.Intf}, nil
 		}
-		return nil, errors.New("invalid interface type")
+		return nil, fs.errorAt(e, "unsupported interface type %s", fs.Format(e))
 
 	default: // other types not supported
-		return nil, errors.New("types not supported")
+		return nil, fs.errorAt(e, "unsupported type %s", fs.Format(e))
 	}
 }
 
-var Logf func(s string, v ...interface{})
-
-func infof(s string, v ...interface{}) {
-	if Logf != nil {
-		pushstate(s)
-		Logf("info: "+strings.Join(logctx, ": "), v...)
-		popstate()
+func (f *FileSet) infof(s string, v ...interface{}) {
+	if f.logf != nil {
+		f.pushstate(s)
+		f.logf("info: "+strings.Join(f.logctx, ": "), v...)
+		f.popstate()
 	}
 }
 
-func warnf(s string, v ...interface{}) {
-	if Logf != nil {
-		pushstate(s)
-		Logf("warn: "+strings.Join(logctx, ": "), v...)
-		popstate()
+func (f *FileSet) warnf(s string, v ...interface{}) {
+	if f.logf != nil {
+		f.pushstate(s)
+		f.logf("warn: "+strings.Join(f.logctx, ": "), v...)
+		f.popstate()
 	}
 }
 
-var logctx []string
-
 // push logging state
-func pushstate(s string) {
-	logctx = append(logctx, s)
+func (f *FileSet) pushstate(s string) {
+	f.logctx = append(f.logctx, s)
 }
 
 // pop logging state
-func popstate() {
-	logctx = logctx[:len(logctx)-1]
+func (f *FileSet) popstate() {
+	f.logctx = f.logctx[:len(f.logctx)-1]
 }
@@ -36,7 +36,7 @@ const maxComplex = 5
 // given name and replace them with e
 func (f *FileSet) findShim(id string, e gen.Elem, addID bool) {
 	for name, el := range f.Identities {
-		pushstate(name)
+		f.pushstate(name)
 		switch el := el.(type) {
 		case *gen.Struct:
 			for i := range el.Fields {
@@ -47,11 +47,12 @@ func (f *FileSet) findShim(id string, e gen.Elem, addID bool) {
 		case *gen.Slice:
 			f.nextShim(&el.Els, id, e)
 		case *gen.Map:
+			f.nextShim(&el.Key, id, e)
 			f.nextShim(&el.Value, id, e)
 		case *gen.Ptr:
 			f.nextShim(&el.Value, id, e)
 		}
-		popstate()
+		f.popstate()
 	}
 	if addID {
 		f.Identities[id] = e
@@ -60,9 +61,8 @@ func (f *FileSet) findShim(id string, e gen.Elem, addID bool) {
 
 func (f *FileSet) nextShim(ref *gen.Elem, id string, e gen.Elem) {
 	if (*ref).TypeName() == id {
-		vn := (*ref).Varname()
+		// variable names are set when printing
 		*ref = e.Copy()
-		(*ref).SetVarname(vn)
 	} else {
 		switch el := (*ref).(type) {
 		case *gen.Struct:
@@ -74,6 +74,7 @@ func (f *FileSet) nextShim(ref *gen.Elem, id string, e gen.Elem) {
 		case *gen.Slice:
 			f.nextShim(&el.Els, id, e)
 		case *gen.Map:
+			f.nextShim(&el.Key, id, e)
 			f.nextShim(&el.Value, id, e)
 		case *gen.Ptr:
 			f.nextShim(&el.Value, id, e)
@@ -82,7 +83,7 @@ func (f *FileSet) nextShim(ref *gen.Elem, id string, e gen.Elem) {
 }
 
 // propInline identifies and inlines candidates
-func (f *FileSet) propInline() (err error) {
+func (f *FileSet) propInline() {
 	type gelem struct {
 		name string
 		el   gen.Elem
@@ -105,38 +106,34 @@ func (f *FileSet) propInline() (err error) {
 
 	for i := range all {
 		name := all[i].name
-		pushstate(name)
+		f.pushstate(name)
+		var err error
 		switch el := all[i].el.(type) {
 		case *gen.Struct:
-			tagsValue := [256]uint16{}
 			for i := range el.Fields {
 				err2 := f.nextInline(&el.Fields[i].FieldElem, name)
 				if err2 != nil {
 					err = err2
 				}
-				if tagsValue[el.Fields[i].FieldTag] != 0 {
-					err = errors.New("tag value repeated")
-					warnf("tag value %d repeated", el.Fields[i].FieldTag)
-					continue
-				}
-				tagsValue[el.Fields[i].FieldTag] = el.Fields[i].FieldTag
+			}
+			if tag, ok := dupFieldTag(el.Fields); ok {
+				f.warnf("tag value %d repeated\n", tag)
+				err = fmt.Errorf("field index %d repeated", tag)
 			}
 		case *gen.Array:
 			err = f.nextInline(&el.Els, name)
 		case *gen.Slice:
 			err = f.nextInline(&el.Els, name)
 		case *gen.Map:
-			err = f.nextInline(&el.Value, name)
+			err = f.inlineMap(el, name)
 		case *gen.Ptr:
 			err = f.nextInline(&el.Value, name)
 		}
-		popstate()
+		f.popstate()
 		if err != nil {
-			return err
+			f.addError(f.errorAt(f.Specs[name], "type %s: %s", name, err))
 		}
 	}
-
-	return nil
 }
 
 const fatalloop = `detected infinite recursion in inlining loop!
@@ -151,8 +148,10 @@ func (f *FileSet) nextInline(ref *gen.Elem, root string) (err error) {
 		// a type into itself
 		typ := el.TypeName()
 		if el.Value == gen.IDENT && typ != root {
-			if node, ok := f.Identities[typ]; ok && node.Complexity() < maxComplex {
-				infof("inlining %s\n", typ)
+			// types with their own options are printed with them,
+			// so they're called rather than inlined without them
+			if node, ok := f.Identities[typ]; ok && node.Complexity() < maxComplex && !f.hasTypeOpts(typ) {
+				f.infof("inlining %s\n", typ)
 
 				// This should never happen; it will cause
 				// infinite recursion.
@@ -165,35 +164,31 @@ func (f *FileSet) nextInline(ref *gen.Elem, root string) (err error) {
 				if err != nil {
 					return err
 				}
-			} else if !ok && !el.Resolved() {
+			} else if !ok && !el.Resolved() && f.siblingFile(typ) == "" {
 				// this is the point at which we're sure that
 				// we've got a type that isn't a primitive,
 				// a library builtin, or a processed type
-				warnf("unresolved identifier: %s\n", typ)
+				f.warnf("unresolved identifier: %s\n", typ)
 				return fmt.Errorf("unresolved identifier: %s", typ)
 			}
 		}
 	case *gen.Struct:
-		tagsValue := [256]uint16{}
 		for i := range el.Fields {
 			err2 := f.nextInline(&el.Fields[i].FieldElem, root)
 			if err2 != nil {
 				err = err2
 			}
-
-			if tagsValue[el.Fields[i].FieldTag] != 0 {
-				err = errors.New("tag value repeated")
-				warnf("tag value %d repeated", el.Fields[i].FieldTag)
-				continue
-			}
-			tagsValue[el.Fields[i].FieldTag] = el.Fields[i].FieldTag
+		}
+		if tag, ok := dupFieldTag(el.Fields); ok {
+			f.warnf("tag value %d repeated\n", tag)
+			err = fmt.Errorf("field index %d repeated", tag)
 		}
 	case *gen.Array:
 		return f.nextInline(&el.Els, root)
 	case *gen.Slice:
 		return f.nextInline(&el.Els, root)
 	case *gen.Map:
-		return f.nextInline(&el.Value, root)
+		return f.inlineMap(el, root)
 	case *gen.Ptr:
 		return f.nextInline(&el.Value, root)
 	case *gen.CsharpString:
@@ -204,3 +199,41 @@ func (f *FileSet) nextInline(ref *gen.Elem, root string) (err error) {
 	}
 	return nil
 }
+
+// hasTypeOpts reports whether the type name has printer
+// options set by directives in its doc comment.
+func (f *FileSet) hasTypeOpts(name string) bool {
+	return f.typeOpts[name] != (gen.TypeOptions{})
+}
+
+// inlineMap inlines the key and value of a map. Named key
+// types are resolved to the primitive they are written as,
+// so the key has to end up as a string or an integer.
+func (f *FileSet) inlineMap(m *gen.Map, root string) error {
+	if err := f.nextInline(&m.Key, root); err != nil {
+		return err
+	}
+	if !validMapKey(m.Key) {
+		return fmt.Errorf("map key %s must be a string or integer type", m.Key.TypeName())
+	}
+	return f.nextInline(&m.Value, root)
+}
+
+// validMapKey reports whether e can be written as a map key.
+func validMapKey(e gen.Elem) bool {
+	kb, ok := e.(*gen.BaseElem)
+	if !ok {
+		return false
+	}
+	if kb.ShimToBase != "" && kb.ShimMode == gen.Convert {
+		// conversions that may fail are not supported for keys
+		return false
+	}
+	switch kb.Value {
+	case gen.String, gen.Byte,
+		gen.Int, gen.Int8, gen.Int16, gen.Int32, gen.Int64,
+		gen.Uint, gen.Uint8, gen.Uint16, gen.Uint32, gen.Uint64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package parse
+
+import (
+	"go/ast"
+	"sort"
+
+	"github.com/aggronmagi/csmsgp2go/gen"
+)
+
+// LintCsharp reports each field whose wire form can't
+// round-trip with a MessagePack-CSharp type, with the
+// C# type to use instead. It returns an ErrorList, or nil.
+func (f *FileSet) LintCsharp() error {
+	names := make([]string, 0, len(f.Identities))
+	for name := range f.Identities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f.pushstate(name)
+		l := csharpLint{f: f, newTime: f.NewTime || f.typeOpts[name].NewTime}
+		l.elem(f.Identities[name], f.Specs[name], "type "+name, name)
+		f.popstate()
+	}
+	return f.err()
+}
+
+// csharpLint walks an Elem and the AST it was parsed from.
+type csharpLint struct {
+	f       *FileSet
+	newTime bool // time.Time is written as the timestamp extension
+}
+
+// elem checks e, parsed from node, which is described as what.
+// path names e in the messages. node may be nil, or a named
+// type that was inlined; the children of e then have no
+// nodes of their own, and are reported at node.
+func (l *csharpLint) elem(e gen.Elem, node ast.Expr, what, path string) {
+	if id, ok := node.(*ast.Ident); ok && l.f.Identities[id.Name] != nil {
+		// a processed type; it is checked on its own
+		return
+	}
+	switch e := e.(type) {
+	case *gen.Struct:
+		st, _ := node.(*ast.StructType)
+		for i := range e.Fields {
+			sf := &e.Fields[i]
+			if _, ok := sf.FieldElem.(*gen.NilPlaceholder); ok {
+				continue
+			}
+			fn := path + "." + sf.FieldName
+			l.elem(sf.FieldElem, l.field(st, sf.FieldName, node), "field "+fn, fn)
+		}
+	case *gen.Slice:
+		l.elem(e.Els, arrayElt(node), what, path+"[]")
+	case *gen.Array:
+		l.elem(e.Els, arrayElt(node), what, path+"[]")
+	case *gen.Map:
+		if m, ok := node.(*ast.MapType); ok {
+			l.elem(e.Key, m.Key, what, path+" key")
+			l.elem(e.Value, m.Value, what, path+"[]")
+		} else {
+			l.elem(e.Key, node, what, path+" key")
+			l.elem(e.Value, node, what, path+"[]")
+		}
+	case *gen.BaseElem:
+		if msg := l.base(e); msg != "" {
+			l.f.addError(l.f.errorAt(node, "%s: %s", what, msg))
+		}
+	}
+}
+
+// arrayElt returns the element type of an array node,
+// or node itself if it is not an array.
+func arrayElt(node ast.Expr) ast.Expr {
+	if a, ok := node.(*ast.ArrayType); ok {
+		return a.Elt
+	}
+	return node
+}
+
+// field returns the type of the field name in st, or
+// parent if st is nil or the field is not declared in it.
+func (l *csharpLint) field(st *ast.StructType, name string, parent ast.Expr) ast.Expr {
+	if st == nil {
+		return parent
+	}
+	for _, fl := range st.Fields.List {
+		if len(fl.Names) == 0 && embedded(fl.Type) == name {
+			return fl.Type
+		}
+		for _, n := range fl.Names {
+			if n.Name == name {
+				return fl.Type
+			}
+		}
+	}
+	return parent
+}
+
+// base returns what is wrong with the wire form of b
+// in C#, and what to use instead, or "".
+func (l *csharpLint) base(b *gen.BaseElem) string {
+	if b.Value == gen.IDENT {
+		if b.TypeName() == "msgp.Number" {
+			return "msgp.Number may be an integer or a float on the wire; use float64 (C# double) or int64 (C# long)"
+		}
+		return ""
+	}
+	switch b.Value {
+	case gen.Complex64:
+		return "complex64 is written as a msgp extension that C# can't read; use two float32 fields (C# float)"
+	case gen.Complex128:
+		return "complex128 is written as a msgp extension that C# can't read; use two float64 fields (C# double)"
+	case gen.Uint:
+		return "uint has a platform-dependent size; use uint64 (C# ulong) or uint32 (C# uint)"
+	case gen.Int:
+		return "int has a platform-dependent size; use int64 (C# long) or int32 (C# int)"
+	case gen.Intf:
+		return "interface{} only round-trips with the C# Typeless resolver; use a concrete type (C# object needs TypelessFormatter)"
+	case gen.JsonNumber:
+		return "json.Number may be an integer or a float on the wire; use float64 (C# double) or int64 (C# long)"
+	case gen.Duration:
+		return "time.Duration is written as int64 nanoseconds; use //msgp:timespan or the timespan tag (C# TimeSpan)"
+	case gen.Time:
+		if !l.newTime {
+			return "time.Time is written as msgp extension 5; use //msgp:newtime (C# DateTime)"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,167 @@
+package parse
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/types"
+	"path"
+	"strings"
+
+	"github.com/aggronmagi/csmsgp2go/gen"
+)
+
+// marshalerKind is the standard library encoding
+// interface pair implemented by a named type.
+type marshalerKind int
+
+const (
+	noMarshaler     marshalerKind = iota
+	textMarshaler                 // encoding.TextMarshaler and encoding.TextUnmarshaler
+	binaryMarshaler               // encoding.BinaryMarshaler and encoding.BinaryUnmarshaler
+)
+
+// collectMethods records the method names declared
+// in f for each receiver type name.
+func (fs *FileSet) collectMethods(f *ast.File) {
+	for _, d := range f.Decls {
+		fn, ok := d.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || len(fn.Recv.List) != 1 {
+			continue
+		}
+		recv := fn.Recv.List[0].Type
+		if star, ok := recv.(*ast.StarExpr); ok {
+			recv = star.X
+		}
+		id, ok := recv.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		if fs.methods == nil {
+			fs.methods = make(map[string]map[string]bool)
+		}
+		if fs.methods[id.Name] == nil {
+			fs.methods[id.Name] = make(map[string]bool)
+		}
+		fs.methods[id.Name][fn.Name.Name] = true
+	}
+}
+
+// marshalerShims writes unresolved named types that implement
+// the encoding text (or binary) marshaler pair, and no msgp
+// methods, as str (or bin). Other unresolved types are left
+// alone. Shims from directives are applied before this.
+func (fs *FileSet) marshalerShims() {
+	for name := range fs.unresolved() {
+		switch fs.marshalerKind(name) {
+		case textMarshaler:
+			fs.infof("%s -> text\n", name)
+			fs.findShim(name, marshalerShim(name, "string", "Text"), false)
+		case binaryMarshaler:
+			fs.infof("%s -> binary\n", name)
+			fs.findShim(name, marshalerShim(name, "[]byte", "Binary"), false)
+		}
+	}
+}
+
+// unresolved returns the names of the named types used
+// by the processed types that are neither processed nor
+// builtin, nor converted by a shim, nor declared in the
+// other files of the package.
+func (fs *FileSet) unresolved() map[string]struct{} {
+	names := make(map[string]struct{})
+	for _, el := range fs.Identities {
+		walkIdents(el, func(b *gen.BaseElem) {
+			if b.Value == gen.IDENT && !b.Convert && !b.Resolved() {
+				if _, ok := fs.Identities[b.TypeName()]; !ok && fs.siblingFile(b.TypeName()) == "" {
+					names[b.TypeName()] = struct{}{}
+				}
+			}
+		})
+	}
+	return names
+}
+
+// marshalerShim converts name to base with the
+// csmsgp Marshal{kind}/Unmarshal{kind} functions.
+func marshalerShim(name, base, kind string) *gen.BaseElem {
+	be := gen.Ident(base)
+	if base == "[]byte" {
+		be = &gen.BaseElem{Value: gen.Bytes}
+	}
+	be.Alias(name)
+	be.ShimToBase = "csmsgp.Marshal" + kind
+	be.ShimFromBase = "csmsgp.Unmarshal" + kind + "[" + name + "]"
+	be.ShimMode = gen.Convert
+	return be
+}
+
+// marshalerKind looks up the methods of the named type,
+// either in the parsed files or in the imported package.
+func (fs *FileSet) marshalerKind(name string) marshalerKind {
+	has := func(m string) bool { return fs.methods[name][m] }
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		obj := fs.lookupImported(name[:i], name[i+1:])
+		if obj == nil {
+			return noMarshaler
+		}
+		mset := types.NewMethodSet(types.NewPointer(obj.Type()))
+		has = func(m string) bool { return mset.Lookup(obj.Pkg(), m) != nil }
+	}
+	switch {
+	case has("MarshalMsg") || has("UnmarshalMsg"):
+		return noMarshaler
+	case has("MarshalText") && has("UnmarshalText"):
+		return textMarshaler
+	case has("MarshalBinary") && has("UnmarshalBinary"):
+		return binaryMarshaler
+	}
+	return noMarshaler
+}
+
+// lookupImported finds the type sel of the package
+// imported as pkg, type-checking it from source.
+func (fs *FileSet) lookupImported(pkg, sel string) types.Object {
+	if fs.importer == nil {
+		fs.importer = importer.ForCompiler(fs.FSet, "source", nil).(types.ImporterFrom)
+	}
+	for _, imp := range fs.Imports {
+		ipath := strings.Trim(imp.Path.Value, `"`)
+		switch {
+		case imp.Name != nil && imp.Name.Name != pkg:
+			continue
+		case imp.Name == nil && path.Base(ipath) != pkg:
+			// the package name usually matches the last path element
+			continue
+		}
+		p, err := fs.importer.ImportFrom(ipath, fs.dir, 0)
+		if err != nil {
+			fs.warnf("importing %s: %s\n", ipath, err)
+			continue
+		}
+		if obj, ok := p.Scope().Lookup(sel).(*types.TypeName); ok {
+			return obj
+		}
+	}
+	return nil
+}
+
+// walkIdents calls fn for each *gen.BaseElem in e.
+func walkIdents(e gen.Elem, fn func(*gen.BaseElem)) {
+	switch e := e.(type) {
+	case *gen.BaseElem:
+		fn(e)
+	case *gen.Struct:
+		for i := range e.Fields {
+			walkIdents(e.Fields[i].FieldElem, fn)
+		}
+	case *gen.Array:
+		walkIdents(e.Els, fn)
+	case *gen.Slice:
+		walkIdents(e.Els, fn)
+	case *gen.Map:
+		walkIdents(e.Key, fn)
+		walkIdents(e.Value, fn)
+	case *gen.Ptr:
+		walkIdents(e.Value, fn)
+	}
+}
@@ -0,0 +1,116 @@
+package parse
+
+import (
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// siblings holds the type declarations in the files of the
+// package that were not parsed, like the other files of the
+// package when a single file is parsed.
+type siblings struct {
+	specs   map[string]ast.Expr // defined types
+	aliases map[string]ast.Expr // alias targets
+	files   map[string]string   // file declaring each type
+}
+
+// sibling returns the declaration of the type name in the
+// other files of the package, and whether it is an alias.
+// The files are read the first time it is called.
+func (f *FileSet) sibling(name string) (def ast.Expr, alias, ok bool) {
+	if f.sibs == nil {
+		f.sibs = f.loadSiblings()
+	}
+	if def, ok = f.sibs.aliases[name]; ok {
+		return def, true, true
+	}
+	def, ok = f.sibs.specs[name]
+	return def, false, ok
+}
+
+// loadSiblings parses the files in f.dir of the same package,
+// with the same build tags, that are not test files, not
+// generated, and not already parsed. Files that can't be read
+// or parsed are skipped; errors in them are not ours to report.
+func (f *FileSet) loadSiblings() *siblings {
+	s := &siblings{
+		specs:   make(map[string]ast.Expr),
+		aliases: make(map[string]ast.Expr),
+		files:   make(map[string]string),
+	}
+	ents, err := os.ReadDir(f.dir)
+	if err != nil {
+		return s
+	}
+	bctx := build.Default
+	bctx.BuildTags = f.tags
+	for _, ent := range ents {
+		name := ent.Name()
+		if ent.IsDir() || !strings.HasSuffix(name, ".go") ||
+			strings.HasSuffix(name, "_test.go") || strings.HasSuffix(name, "_gen.go") {
+			continue
+		}
+		path := filepath.Join(f.dir, name)
+		if f.parsed[absPath(path)] {
+			continue
+		}
+		if ok, err := bctx.MatchFile(f.dir, name); err != nil || !ok {
+			continue
+		}
+		fl, err := parser.ParseFile(f.FSet, path, nil, parser.ParseComments)
+		if err != nil || fl.Name.Name != f.Package || isGenerated(fl) {
+			continue
+		}
+		f.fileImports[path] = fl.Imports
+		for _, d := range fl.Decls {
+			g, ok := d.(*ast.GenDecl)
+			if !ok || g.Tok != token.TYPE {
+				continue
+			}
+			for _, sp := range g.Specs {
+				ts := sp.(*ast.TypeSpec)
+				if ts.Assign.IsValid() {
+					s.aliases[ts.Name.Name] = ts.Type
+				} else {
+					s.specs[ts.Name.Name] = ts.Type
+				}
+				s.files[ts.Name.Name] = name
+			}
+		}
+	}
+	return s
+}
+
+// siblingFile returns the file in the package
+// declaring the type name, or "".
+func (f *FileSet) siblingFile(name string) string {
+	if _, _, ok := f.sibling(name); !ok {
+		return ""
+	}
+	return f.sibs.files[name]
+}
+
+// aliasTarget returns the type the alias name stands for,
+// if name is an alias declared in the package.
+func (f *FileSet) aliasTarget(name string) (ast.Expr, bool) {
+	if def, ok := f.aliases[name]; ok {
+		return def, true
+	}
+	if _, ok := f.Specs[name]; ok {
+		return nil, false
+	}
+	def, alias, ok := f.sibling(name)
+	return def, ok && alias
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
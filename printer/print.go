@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"os"
 	"strings"
 
 	"github.com/aggronmagi/csmsgp2go/gen"
@@ -12,15 +11,21 @@ import (
 	"golang.org/x/tools/imports"
 )
 
-var Logf func(s string, v ...interface{})
+// TestFile returns the name of the tests for
+// the generated file named file.
+func TestFile(file string) string {
+	return strings.TrimSuffix(file, ".go") + "_test.go"
+}
 
-// PrintFile prints the methods for the provided list
-// of elements to the given file name and canonical
-// package path.
-func PrintFile(file string, f *parse.FileSet, mode gen.Method) error {
-	out, tests, err := generate(f, mode)
+// Generate returns the formatted methods and, if mode
+// has gen.Test, tests for f. file is the name of the
+// generated file; goimports resolves imports from its
+// directory. If the code can't be formatted, it is
+// returned unformatted along with the error.
+func Generate(file string, f *parse.FileSet, mode gen.Method) (code, tests []byte, err error) {
+	out, testbuf, err := generate(f, mode)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	// we'll run goimports on the main file
@@ -30,45 +35,32 @@ func PrintFile(file string, f *parse.FileSet, mode gen.Method) error {
 	// doing them in serial when GOMAXPROCS=1,
 	// and faster otherwise.
 	res := goformat(file, out.Bytes())
-	if tests != nil {
-		testfile := strings.TrimSuffix(file, ".go") + "_test.go"
-		err = format(testfile, tests.Bytes())
+	if testbuf != nil {
+		tests, err = imports.Process(TestFile(file), testbuf.Bytes(), nil)
 		if err != nil {
-			return err
-		}
-		if Logf != nil {
-			Logf("Wrote and formatted \"%s\"\n", testfile)
+			<-res
+			return nil, nil, err
 		}
 	}
-	err = <-res
-	if err != nil {
-		os.WriteFile(file+".broken", out.Bytes(), os.ModePerm)
-		if Logf != nil {
-			Logf("Error: %s. Wrote broken output to %s\n", err, file+".broken")
-		}
-
-		return err
+	r := <-res
+	if r.err != nil {
+		return out.Bytes(), nil, r.err
 	}
-	return nil
+	return r.out, tests, nil
 }
 
-func format(file string, data []byte) error {
-	out, err := imports.Process(file, data, nil)
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(file, out, 0o600)
+type formatted struct {
+	out []byte
+	err error
 }
 
-func goformat(file string, data []byte) <-chan error {
-	out := make(chan error, 1)
-	go func(file string, data []byte, end chan error) {
-		end <- format(file, data)
-		if Logf != nil {
-			Logf("Wrote and formatted \"%s\"\n", file)
-		}
-	}(file, data, out)
-	return out
+func goformat(file string, data []byte) <-chan formatted {
+	res := make(chan formatted, 1)
+	go func() {
+		out, err := imports.Process(file, data, nil)
+		res <- formatted{out, err}
+	}()
+	return res
 }
 
 func dedupImports(imp []string) []string {
@@ -84,10 +76,17 @@ func dedupImports(imp []string) []string {
 }
 
 func generate(f *parse.FileSet, mode gen.Method) (*bytes.Buffer, *bytes.Buffer, error) {
+	if len(f.Exts) > 0 && mode&(gen.Marshal|gen.Unmarshal) != gen.Marshal|gen.Unmarshal {
+		return nil, nil, fmt.Errorf("msgp:ext requires Marshal and Unmarshal methods; use -marshal")
+	}
 	outbuf := bytes.NewBuffer(make([]byte, 0, 4096))
 	writePkgHeader(outbuf, f.Package)
 
-	myImports := []string{"github.com/tinylib/msgp/msgp"}
+	// unused imports are dropped by goimports.
+	myImports := []string{"github.com/tinylib/msgp/msgp", "github.com/aggronmagi/csmsgp2go/csmsgp"}
+	if mode&gen.Diff == gen.Diff {
+		myImports = append(myImports, "bytes", "reflect")
+	}
 	for _, imp := range f.Imports {
 		if imp.Name != nil {
 			// have an alias, include it.
@@ -104,11 +103,8 @@ func generate(f *parse.FileSet, mode gen.Method) (*bytes.Buffer, *bytes.Buffer,
 	if mode&gen.Test == gen.Test {
 		testbuf = bytes.NewBuffer(make([]byte, 0, 4096))
 		writePkgHeader(testbuf, f.Package)
-		if mode&(gen.Encode|gen.Decode) != 0 {
-			writeImportHeader(testbuf, "bytes", "github.com/tinylib/msgp/msgp", "testing")
-		} else {
-			writeImportHeader(testbuf, "github.com/tinylib/msgp/msgp", "testing")
-		}
+		// unused imports are dropped by goimports.
+		writeImportHeader(testbuf, "bytes", "github.com/tinylib/msgp/msgp", "github.com/aggronmagi/csmsgp2go/csmsgp", "testing")
 		testwr = testbuf
 	}
 	return outbuf, testbuf, f.PrintTo(gen.NewPrinter(mode, outbuf, testwr))
@@ -0,0 +1,68 @@
+// Package unity provides the Unity built-in value types
+// with the wire format of the MessagePack.Unity formatters:
+// every type is a fixed-size array of float32.
+//
+// csmsgp2go treats these types as builtins, so they can be
+// used as fields once this package is imported under its
+// own name:
+//
+//	import "github.com/aggronmagi/csmsgp2go/unity"
+//
+//	type Player struct {
+//		Pos unity.Vector3    `msg:"0"`
+//		Rot unity.Quaternion `msg:"1"`
+//	}
+package unity
+
+//go:generate go run github.com/aggronmagi/csmsgp2go -io -exactsize
+
+// Vector2 is UnityEngine.Vector2, encoded as [x, y].
+type Vector2 struct {
+	X float32 `msg:"0"`
+	Y float32 `msg:"1"`
+}
+
+// Vector3 is UnityEngine.Vector3, encoded as [x, y, z].
+type Vector3 struct {
+	X float32 `msg:"0"`
+	Y float32 `msg:"1"`
+	Z float32 `msg:"2"`
+}
+
+// Vector4 is UnityEngine.Vector4, encoded as [x, y, z, w].
+type Vector4 struct {
+	X float32 `msg:"0"`
+	Y float32 `msg:"1"`
+	Z float32 `msg:"2"`
+	W float32 `msg:"3"`
+}
+
+// Quaternion is UnityEngine.Quaternion, encoded as [x, y, z, w].
+type Quaternion struct {
+	X float32 `msg:"0"`
+	Y float32 `msg:"1"`
+	Z float32 `msg:"2"`
+	W float32 `msg:"3"`
+}
+
+// Color is UnityEngine.Color, encoded as [r, g, b, a].
+type Color struct {
+	R float32 `msg:"0"`
+	G float32 `msg:"1"`
+	B float32 `msg:"2"`
+	A float32 `msg:"3"`
+}
+
+// Rect is UnityEngine.Rect, encoded as [x, y, width, height].
+type Rect struct {
+	X      float32 `msg:"0"`
+	Y      float32 `msg:"1"`
+	Width  float32 `msg:"2"`
+	Height float32 `msg:"3"`
+}
+
+// Bounds is UnityEngine.Bounds, encoded as [center, size].
+type Bounds struct {
+	Center Vector3 `msg:"0"`
+	Size   Vector3 `msg:"1"`
+}
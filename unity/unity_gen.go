@@ -0,0 +1,1135 @@
+package unity
+
+// Code generated by github.com/aggronmagi/csmsgp2go DO NOT EDIT.
+
+import (
+	"github.com/tinylib/msgp/msgp"
+)
+
+// DecodeMsg implements msgp.Decodable
+func (z *Bounds) DecodeMsg(dc *msgp.Reader) (err error) {
+	if dc.IsNil() {
+		err = dc.ReadNil()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		*z = Bounds{}
+	} else {
+		var zb0001 uint32
+		zb0001, err = dc.ReadArrayHeader()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		if zb0001 != 2 {
+			err = msgp.ArrayError{Wanted: 2, Got: zb0001}
+			return
+		}
+		if dc.IsNil() {
+			err = dc.ReadNil()
+			if err != nil {
+				err = msgp.WrapError(err, "Center")
+				return
+			}
+			z.Center = Vector3{}
+		} else {
+			var zb0002 uint32
+			zb0002, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Center")
+				return
+			}
+			if zb0002 != 3 {
+				err = msgp.ArrayError{Wanted: 3, Got: zb0002}
+				return
+			}
+			z.Center.X, err = dc.ReadFloat32()
+			if err != nil {
+				err = msgp.WrapError(err, "Center", "X")
+				return
+			}
+			z.Center.Y, err = dc.ReadFloat32()
+			if err != nil {
+				err = msgp.WrapError(err, "Center", "Y")
+				return
+			}
+			z.Center.Z, err = dc.ReadFloat32()
+			if err != nil {
+				err = msgp.WrapError(err, "Center", "Z")
+				return
+			}
+		}
+		if dc.IsNil() {
+			err = dc.ReadNil()
+			if err != nil {
+				err = msgp.WrapError(err, "Size")
+				return
+			}
+			z.Size = Vector3{}
+		} else {
+			var zb0003 uint32
+			zb0003, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Size")
+				return
+			}
+			if zb0003 != 3 {
+				err = msgp.ArrayError{Wanted: 3, Got: zb0003}
+				return
+			}
+			z.Size.X, err = dc.ReadFloat32()
+			if err != nil {
+				err = msgp.WrapError(err, "Size", "X")
+				return
+			}
+			z.Size.Y, err = dc.ReadFloat32()
+			if err != nil {
+				err = msgp.WrapError(err, "Size", "Y")
+				return
+			}
+			z.Size.Z, err = dc.ReadFloat32()
+			if err != nil {
+				err = msgp.WrapError(err, "Size", "Z")
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Bounds) EncodeMsg(en *msgp.Writer) (err error) {
+	// array header, size 2
+	// array header, size 3
+	err = en.Append(0x92, 0x93)
+	if err != nil {
+		return
+	}
+	err = en.WriteFloat32(z.Center.X)
+	if err != nil {
+		err = msgp.WrapError(err, "Center", "X")
+		return
+	}
+	err = en.WriteFloat32(z.Center.Y)
+	if err != nil {
+		err = msgp.WrapError(err, "Center", "Y")
+		return
+	}
+	err = en.WriteFloat32(z.Center.Z)
+	if err != nil {
+		err = msgp.WrapError(err, "Center", "Z")
+		return
+	}
+	// array header, size 3
+	err = en.Append(0x93)
+	if err != nil {
+		return
+	}
+	err = en.WriteFloat32(z.Size.X)
+	if err != nil {
+		err = msgp.WrapError(err, "Size", "X")
+		return
+	}
+	err = en.WriteFloat32(z.Size.Y)
+	if err != nil {
+		err = msgp.WrapError(err, "Size", "Y")
+		return
+	}
+	err = en.WriteFloat32(z.Size.Z)
+	if err != nil {
+		err = msgp.WrapError(err, "Size", "Z")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Bounds) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// array header, size 2
+	// idx 0
+	// array header, size 3
+	// idx 0
+	o = append(o, 0x92, 0x93)
+	o = msgp.AppendFloat32(o, z.Center.X)
+	// idx 1
+	o = msgp.AppendFloat32(o, z.Center.Y)
+	// idx 2
+	o = msgp.AppendFloat32(o, z.Center.Z)
+	// idx 1
+	// array header, size 3
+	// idx 0
+	o = append(o, 0x93)
+	o = msgp.AppendFloat32(o, z.Size.X)
+	// idx 1
+	o = msgp.AppendFloat32(o, z.Size.Y)
+	// idx 2
+	o = msgp.AppendFloat32(o, z.Size.Z)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Bounds) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	if msgp.IsNil(bts) {
+		bts, err = msgp.ReadNilBytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		*z = Bounds{}
+	} else {
+		var zb0001 uint32
+		zb0001, bts, err = msgp.ReadArrayHeaderBytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		if zb0001 != 2 {
+			err = msgp.ArrayError{Wanted: 2, Got: zb0001}
+			return
+		}
+		// idx 0
+		if msgp.IsNil(bts) {
+			bts, err = msgp.ReadNilBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Center")
+				return
+			}
+			z.Center = Vector3{}
+		} else {
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Center")
+				return
+			}
+			if zb0002 != 3 {
+				err = msgp.ArrayError{Wanted: 3, Got: zb0002}
+				return
+			}
+			// idx 0
+			z.Center.X, bts, err = msgp.ReadFloat32Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Center", "X")
+				return
+			}
+			// idx 1
+			z.Center.Y, bts, err = msgp.ReadFloat32Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Center", "Y")
+				return
+			}
+			// idx 2
+			z.Center.Z, bts, err = msgp.ReadFloat32Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Center", "Z")
+				return
+			}
+		}
+		// idx 1
+		if msgp.IsNil(bts) {
+			bts, err = msgp.ReadNilBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Size")
+				return
+			}
+			z.Size = Vector3{}
+		} else {
+			var zb0003 uint32
+			zb0003, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Size")
+				return
+			}
+			if zb0003 != 3 {
+				err = msgp.ArrayError{Wanted: 3, Got: zb0003}
+				return
+			}
+			// idx 0
+			z.Size.X, bts, err = msgp.ReadFloat32Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Size", "X")
+				return
+			}
+			// idx 1
+			z.Size.Y, bts, err = msgp.ReadFloat32Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Size", "Y")
+				return
+			}
+			// idx 2
+			z.Size.Z, bts, err = msgp.ReadFloat32Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Size", "Z")
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Bounds) Msgsize() (s int) {
+	s = 1 /* idx 0 */ + 1 /* idx 0 */ + msgp.Float32Size /* idx 1 */ + msgp.Float32Size /* idx 2 */ + msgp.Float32Size /* idx 1 */ + 1 /* idx 0 */ + msgp.Float32Size /* idx 1 */ + msgp.Float32Size /* idx 2 */ + msgp.Float32Size
+	return
+}
+
+// ExactMsgsize returns the exact number of bytes occupied by the serialized message
+func (z *Bounds) ExactMsgsize() (s int) {
+	s = 1 + 1 + msgp.Float32Size + msgp.Float32Size + msgp.Float32Size + 1 + msgp.Float32Size + msgp.Float32Size + msgp.Float32Size
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *Color) DecodeMsg(dc *msgp.Reader) (err error) {
+	if dc.IsNil() {
+		err = dc.ReadNil()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		*z = Color{}
+	} else {
+		var zb0001 uint32
+		zb0001, err = dc.ReadArrayHeader()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		if zb0001 != 4 {
+			err = msgp.ArrayError{Wanted: 4, Got: zb0001}
+			return
+		}
+		z.R, err = dc.ReadFloat32()
+		if err != nil {
+			err = msgp.WrapError(err, "R")
+			return
+		}
+		z.G, err = dc.ReadFloat32()
+		if err != nil {
+			err = msgp.WrapError(err, "G")
+			return
+		}
+		z.B, err = dc.ReadFloat32()
+		if err != nil {
+			err = msgp.WrapError(err, "B")
+			return
+		}
+		z.A, err = dc.ReadFloat32()
+		if err != nil {
+			err = msgp.WrapError(err, "A")
+			return
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Color) EncodeMsg(en *msgp.Writer) (err error) {
+	// array header, size 4
+	err = en.Append(0x94)
+	if err != nil {
+		return
+	}
+	err = en.WriteFloat32(z.R)
+	if err != nil {
+		err = msgp.WrapError(err, "R")
+		return
+	}
+	err = en.WriteFloat32(z.G)
+	if err != nil {
+		err = msgp.WrapError(err, "G")
+		return
+	}
+	err = en.WriteFloat32(z.B)
+	if err != nil {
+		err = msgp.WrapError(err, "B")
+		return
+	}
+	err = en.WriteFloat32(z.A)
+	if err != nil {
+		err = msgp.WrapError(err, "A")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Color) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// array header, size 4
+	// idx 0
+	o = append(o, 0x94)
+	o = msgp.AppendFloat32(o, z.R)
+	// idx 1
+	o = msgp.AppendFloat32(o, z.G)
+	// idx 2
+	o = msgp.AppendFloat32(o, z.B)
+	// idx 3
+	o = msgp.AppendFloat32(o, z.A)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Color) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	if msgp.IsNil(bts) {
+		bts, err = msgp.ReadNilBytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		*z = Color{}
+	} else {
+		var zb0001 uint32
+		zb0001, bts, err = msgp.ReadArrayHeaderBytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		if zb0001 != 4 {
+			err = msgp.ArrayError{Wanted: 4, Got: zb0001}
+			return
+		}
+		// idx 0
+		z.R, bts, err = msgp.ReadFloat32Bytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err, "R")
+			return
+		}
+		// idx 1
+		z.G, bts, err = msgp.ReadFloat32Bytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err, "G")
+			return
+		}
+		// idx 2
+		z.B, bts, err = msgp.ReadFloat32Bytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err, "B")
+			return
+		}
+		// idx 3
+		z.A, bts, err = msgp.ReadFloat32Bytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err, "A")
+			return
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Color) Msgsize() (s int) {
+	s = 1 /* idx 0 */ + msgp.Float32Size /* idx 1 */ + msgp.Float32Size /* idx 2 */ + msgp.Float32Size /* idx 3 */ + msgp.Float32Size
+	return
+}
+
+// ExactMsgsize returns the exact number of bytes occupied by the serialized message
+func (z *Color) ExactMsgsize() (s int) {
+	s = 1 + msgp.Float32Size + msgp.Float32Size + msgp.Float32Size + msgp.Float32Size
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *Quaternion) DecodeMsg(dc *msgp.Reader) (err error) {
+	if dc.IsNil() {
+		err = dc.ReadNil()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		*z = Quaternion{}
+	} else {
+		var zb0001 uint32
+		zb0001, err = dc.ReadArrayHeader()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		if zb0001 != 4 {
+			err = msgp.ArrayError{Wanted: 4, Got: zb0001}
+			return
+		}
+		z.X, err = dc.ReadFloat32()
+		if err != nil {
+			err = msgp.WrapError(err, "X")
+			return
+		}
+		z.Y, err = dc.ReadFloat32()
+		if err != nil {
+			err = msgp.WrapError(err, "Y")
+			return
+		}
+		z.Z, err = dc.ReadFloat32()
+		if err != nil {
+			err = msgp.WrapError(err, "Z")
+			return
+		}
+		z.W, err = dc.ReadFloat32()
+		if err != nil {
+			err = msgp.WrapError(err, "W")
+			return
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Quaternion) EncodeMsg(en *msgp.Writer) (err error) {
+	// array header, size 4
+	err = en.Append(0x94)
+	if err != nil {
+		return
+	}
+	err = en.WriteFloat32(z.X)
+	if err != nil {
+		err = msgp.WrapError(err, "X")
+		return
+	}
+	err = en.WriteFloat32(z.Y)
+	if err != nil {
+		err = msgp.WrapError(err, "Y")
+		return
+	}
+	err = en.WriteFloat32(z.Z)
+	if err != nil {
+		err = msgp.WrapError(err, "Z")
+		return
+	}
+	err = en.WriteFloat32(z.W)
+	if err != nil {
+		err = msgp.WrapError(err, "W")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Quaternion) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// array header, size 4
+	// idx 0
+	o = append(o, 0x94)
+	o = msgp.AppendFloat32(o, z.X)
+	// idx 1
+	o = msgp.AppendFloat32(o, z.Y)
+	// idx 2
+	o = msgp.AppendFloat32(o, z.Z)
+	// idx 3
+	o = msgp.AppendFloat32(o, z.W)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Quaternion) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	if msgp.IsNil(bts) {
+		bts, err = msgp.ReadNilBytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		*z = Quaternion{}
+	} else {
+		var zb0001 uint32
+		zb0001, bts, err = msgp.ReadArrayHeaderBytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		if zb0001 != 4 {
+			err = msgp.ArrayError{Wanted: 4, Got: zb0001}
+			return
+		}
+		// idx 0
+		z.X, bts, err = msgp.ReadFloat32Bytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err, "X")
+			return
+		}
+		// idx 1
+		z.Y, bts, err = msgp.ReadFloat32Bytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err, "Y")
+			return
+		}
+		// idx 2
+		z.Z, bts, err = msgp.ReadFloat32Bytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err, "Z")
+			return
+		}
+		// idx 3
+		z.W, bts, err = msgp.ReadFloat32Bytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err, "W")
+			return
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Quaternion) Msgsize() (s int) {
+	s = 1 /* idx 0 */ + msgp.Float32Size /* idx 1 */ + msgp.Float32Size /* idx 2 */ + msgp.Float32Size /* idx 3 */ + msgp.Float32Size
+	return
+}
+
+// ExactMsgsize returns the exact number of bytes occupied by the serialized message
+func (z *Quaternion) ExactMsgsize() (s int) {
+	s = 1 + msgp.Float32Size + msgp.Float32Size + msgp.Float32Size + msgp.Float32Size
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *Rect) DecodeMsg(dc *msgp.Reader) (err error) {
+	if dc.IsNil() {
+		err = dc.ReadNil()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		*z = Rect{}
+	} else {
+		var zb0001 uint32
+		zb0001, err = dc.ReadArrayHeader()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		if zb0001 != 4 {
+			err = msgp.ArrayError{Wanted: 4, Got: zb0001}
+			return
+		}
+		z.X, err = dc.ReadFloat32()
+		if err != nil {
+			err = msgp.WrapError(err, "X")
+			return
+		}
+		z.Y, err = dc.ReadFloat32()
+		if err != nil {
+			err = msgp.WrapError(err, "Y")
+			return
+		}
+		z.Width, err = dc.ReadFloat32()
+		if err != nil {
+			err = msgp.WrapError(err, "Width")
+			return
+		}
+		z.Height, err = dc.ReadFloat32()
+		if err != nil {
+			err = msgp.WrapError(err, "Height")
+			return
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Rect) EncodeMsg(en *msgp.Writer) (err error) {
+	// array header, size 4
+	err = en.Append(0x94)
+	if err != nil {
+		return
+	}
+	err = en.WriteFloat32(z.X)
+	if err != nil {
+		err = msgp.WrapError(err, "X")
+		return
+	}
+	err = en.WriteFloat32(z.Y)
+	if err != nil {
+		err = msgp.WrapError(err, "Y")
+		return
+	}
+	err = en.WriteFloat32(z.Width)
+	if err != nil {
+		err = msgp.WrapError(err, "Width")
+		return
+	}
+	err = en.WriteFloat32(z.Height)
+	if err != nil {
+		err = msgp.WrapError(err, "Height")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Rect) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// array header, size 4
+	// idx 0
+	o = append(o, 0x94)
+	o = msgp.AppendFloat32(o, z.X)
+	// idx 1
+	o = msgp.AppendFloat32(o, z.Y)
+	// idx 2
+	o = msgp.AppendFloat32(o, z.Width)
+	// idx 3
+	o = msgp.AppendFloat32(o, z.Height)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Rect) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	if msgp.IsNil(bts) {
+		bts, err = msgp.ReadNilBytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		*z = Rect{}
+	} else {
+		var zb0001 uint32
+		zb0001, bts, err = msgp.ReadArrayHeaderBytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		if zb0001 != 4 {
+			err = msgp.ArrayError{Wanted: 4, Got: zb0001}
+			return
+		}
+		// idx 0
+		z.X, bts, err = msgp.ReadFloat32Bytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err, "X")
+			return
+		}
+		// idx 1
+		z.Y, bts, err = msgp.ReadFloat32Bytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err, "Y")
+			return
+		}
+		// idx 2
+		z.Width, bts, err = msgp.ReadFloat32Bytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err, "Width")
+			return
+		}
+		// idx 3
+		z.Height, bts, err = msgp.ReadFloat32Bytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err, "Height")
+			return
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Rect) Msgsize() (s int) {
+	s = 1 /* idx 0 */ + msgp.Float32Size /* idx 1 */ + msgp.Float32Size /* idx 2 */ + msgp.Float32Size /* idx 3 */ + msgp.Float32Size
+	return
+}
+
+// ExactMsgsize returns the exact number of bytes occupied by the serialized message
+func (z *Rect) ExactMsgsize() (s int) {
+	s = 1 + msgp.Float32Size + msgp.Float32Size + msgp.Float32Size + msgp.Float32Size
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *Vector2) DecodeMsg(dc *msgp.Reader) (err error) {
+	if dc.IsNil() {
+		err = dc.ReadNil()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		*z = Vector2{}
+	} else {
+		var zb0001 uint32
+		zb0001, err = dc.ReadArrayHeader()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		if zb0001 != 2 {
+			err = msgp.ArrayError{Wanted: 2, Got: zb0001}
+			return
+		}
+		z.X, err = dc.ReadFloat32()
+		if err != nil {
+			err = msgp.WrapError(err, "X")
+			return
+		}
+		z.Y, err = dc.ReadFloat32()
+		if err != nil {
+			err = msgp.WrapError(err, "Y")
+			return
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z Vector2) EncodeMsg(en *msgp.Writer) (err error) {
+	// array header, size 2
+	err = en.Append(0x92)
+	if err != nil {
+		return
+	}
+	err = en.WriteFloat32(z.X)
+	if err != nil {
+		err = msgp.WrapError(err, "X")
+		return
+	}
+	err = en.WriteFloat32(z.Y)
+	if err != nil {
+		err = msgp.WrapError(err, "Y")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z Vector2) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// array header, size 2
+	// idx 0
+	o = append(o, 0x92)
+	o = msgp.AppendFloat32(o, z.X)
+	// idx 1
+	o = msgp.AppendFloat32(o, z.Y)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Vector2) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	if msgp.IsNil(bts) {
+		bts, err = msgp.ReadNilBytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		*z = Vector2{}
+	} else {
+		var zb0001 uint32
+		zb0001, bts, err = msgp.ReadArrayHeaderBytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		if zb0001 != 2 {
+			err = msgp.ArrayError{Wanted: 2, Got: zb0001}
+			return
+		}
+		// idx 0
+		z.X, bts, err = msgp.ReadFloat32Bytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err, "X")
+			return
+		}
+		// idx 1
+		z.Y, bts, err = msgp.ReadFloat32Bytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err, "Y")
+			return
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z Vector2) Msgsize() (s int) {
+	s = 1 /* idx 0 */ + msgp.Float32Size /* idx 1 */ + msgp.Float32Size
+	return
+}
+
+// ExactMsgsize returns the exact number of bytes occupied by the serialized message
+func (z Vector2) ExactMsgsize() (s int) {
+	s = 1 + msgp.Float32Size + msgp.Float32Size
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *Vector3) DecodeMsg(dc *msgp.Reader) (err error) {
+	if dc.IsNil() {
+		err = dc.ReadNil()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		*z = Vector3{}
+	} else {
+		var zb0001 uint32
+		zb0001, err = dc.ReadArrayHeader()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		if zb0001 != 3 {
+			err = msgp.ArrayError{Wanted: 3, Got: zb0001}
+			return
+		}
+		z.X, err = dc.ReadFloat32()
+		if err != nil {
+			err = msgp.WrapError(err, "X")
+			return
+		}
+		z.Y, err = dc.ReadFloat32()
+		if err != nil {
+			err = msgp.WrapError(err, "Y")
+			return
+		}
+		z.Z, err = dc.ReadFloat32()
+		if err != nil {
+			err = msgp.WrapError(err, "Z")
+			return
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z Vector3) EncodeMsg(en *msgp.Writer) (err error) {
+	// array header, size 3
+	err = en.Append(0x93)
+	if err != nil {
+		return
+	}
+	err = en.WriteFloat32(z.X)
+	if err != nil {
+		err = msgp.WrapError(err, "X")
+		return
+	}
+	err = en.WriteFloat32(z.Y)
+	if err != nil {
+		err = msgp.WrapError(err, "Y")
+		return
+	}
+	err = en.WriteFloat32(z.Z)
+	if err != nil {
+		err = msgp.WrapError(err, "Z")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z Vector3) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// array header, size 3
+	// idx 0
+	o = append(o, 0x93)
+	o = msgp.AppendFloat32(o, z.X)
+	// idx 1
+	o = msgp.AppendFloat32(o, z.Y)
+	// idx 2
+	o = msgp.AppendFloat32(o, z.Z)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Vector3) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	if msgp.IsNil(bts) {
+		bts, err = msgp.ReadNilBytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		*z = Vector3{}
+	} else {
+		var zb0001 uint32
+		zb0001, bts, err = msgp.ReadArrayHeaderBytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		if zb0001 != 3 {
+			err = msgp.ArrayError{Wanted: 3, Got: zb0001}
+			return
+		}
+		// idx 0
+		z.X, bts, err = msgp.ReadFloat32Bytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err, "X")
+			return
+		}
+		// idx 1
+		z.Y, bts, err = msgp.ReadFloat32Bytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err, "Y")
+			return
+		}
+		// idx 2
+		z.Z, bts, err = msgp.ReadFloat32Bytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err, "Z")
+			return
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z Vector3) Msgsize() (s int) {
+	s = 1 /* idx 0 */ + msgp.Float32Size /* idx 1 */ + msgp.Float32Size /* idx 2 */ + msgp.Float32Size
+	return
+}
+
+// ExactMsgsize returns the exact number of bytes occupied by the serialized message
+func (z Vector3) ExactMsgsize() (s int) {
+	s = 1 + msgp.Float32Size + msgp.Float32Size + msgp.Float32Size
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *Vector4) DecodeMsg(dc *msgp.Reader) (err error) {
+	if dc.IsNil() {
+		err = dc.ReadNil()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		*z = Vector4{}
+	} else {
+		var zb0001 uint32
+		zb0001, err = dc.ReadArrayHeader()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		if zb0001 != 4 {
+			err = msgp.ArrayError{Wanted: 4, Got: zb0001}
+			return
+		}
+		z.X, err = dc.ReadFloat32()
+		if err != nil {
+			err = msgp.WrapError(err, "X")
+			return
+		}
+		z.Y, err = dc.ReadFloat32()
+		if err != nil {
+			err = msgp.WrapError(err, "Y")
+			return
+		}
+		z.Z, err = dc.ReadFloat32()
+		if err != nil {
+			err = msgp.WrapError(err, "Z")
+			return
+		}
+		z.W, err = dc.ReadFloat32()
+		if err != nil {
+			err = msgp.WrapError(err, "W")
+			return
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Vector4) EncodeMsg(en *msgp.Writer) (err error) {
+	// array header, size 4
+	err = en.Append(0x94)
+	if err != nil {
+		return
+	}
+	err = en.WriteFloat32(z.X)
+	if err != nil {
+		err = msgp.WrapError(err, "X")
+		return
+	}
+	err = en.WriteFloat32(z.Y)
+	if err != nil {
+		err = msgp.WrapError(err, "Y")
+		return
+	}
+	err = en.WriteFloat32(z.Z)
+	if err != nil {
+		err = msgp.WrapError(err, "Z")
+		return
+	}
+	err = en.WriteFloat32(z.W)
+	if err != nil {
+		err = msgp.WrapError(err, "W")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Vector4) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// array header, size 4
+	// idx 0
+	o = append(o, 0x94)
+	o = msgp.AppendFloat32(o, z.X)
+	// idx 1
+	o = msgp.AppendFloat32(o, z.Y)
+	// idx 2
+	o = msgp.AppendFloat32(o, z.Z)
+	// idx 3
+	o = msgp.AppendFloat32(o, z.W)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Vector4) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	if msgp.IsNil(bts) {
+		bts, err = msgp.ReadNilBytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		*z = Vector4{}
+	} else {
+		var zb0001 uint32
+		zb0001, bts, err = msgp.ReadArrayHeaderBytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		if zb0001 != 4 {
+			err = msgp.ArrayError{Wanted: 4, Got: zb0001}
+			return
+		}
+		// idx 0
+		z.X, bts, err = msgp.ReadFloat32Bytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err, "X")
+			return
+		}
+		// idx 1
+		z.Y, bts, err = msgp.ReadFloat32Bytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err, "Y")
+			return
+		}
+		// idx 2
+		z.Z, bts, err = msgp.ReadFloat32Bytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err, "Z")
+			return
+		}
+		// idx 3
+		z.W, bts, err = msgp.ReadFloat32Bytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err, "W")
+			return
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Vector4) Msgsize() (s int) {
+	s = 1 /* idx 0 */ + msgp.Float32Size /* idx 1 */ + msgp.Float32Size /* idx 2 */ + msgp.Float32Size /* idx 3 */ + msgp.Float32Size
+	return
+}
+
+// ExactMsgsize returns the exact number of bytes occupied by the serialized message
+func (z *Vector4) ExactMsgsize() (s int) {
+	s = 1 + msgp.Float32Size + msgp.Float32Size + msgp.Float32Size + msgp.Float32Size
+	return
+}
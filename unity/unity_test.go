@@ -0,0 +1,45 @@
+package unity
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// The expected bytes are the output of MessagePack.Unity's
+// formatters for the same values.
+func TestUnityWireFormat(t *testing.T) {
+	v := Vector3{X: 1, Y: 2, Z: 3}
+	bts, err := v.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := hex.DecodeString("93ca3f800000ca40000000ca40400000")
+	if !bytes.Equal(bts, want) {
+		t.Errorf("Vector3 encoded as %x, want %x", bts, want)
+	}
+
+	b := Bounds{Center: Vector3{X: 1}, Size: Vector3{Z: 2}}
+	bts, err = b.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ = hex.DecodeString("92" +
+		"93ca3f800000ca00000000ca00000000" +
+		"93ca00000000ca00000000ca40000000")
+	if !bytes.Equal(bts, want) {
+		t.Errorf("Bounds encoded as %x, want %x", bts, want)
+	}
+}
+
+func TestUnityNoAlloc(t *testing.T) {
+	var q Quaternion
+	buf := make([]byte, 0, 64)
+	allocs := testing.AllocsPerRun(100, func() {
+		bts, _ := (&Quaternion{X: 1, W: 1}).MarshalMsg(buf[:0])
+		_, _ = q.UnmarshalMsg(bts)
+	})
+	if allocs != 0 {
+		t.Errorf("Quaternion round trip allocated %v times", allocs)
+	}
+}